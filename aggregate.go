@@ -0,0 +1,211 @@
+package fmter
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aggregate computes a footer cell from every value in its column.
+type Aggregate int
+
+const (
+	AggregateNone     Aggregate = iota // leave the footer cell as is
+	AggregateSum                       // sum of the numeric values
+	AggregateAvg                       // mean of the numeric values
+	AggregateMin                       // smallest value, as written
+	AggregateMax                       // largest value, as written
+	AggregateCount                     // number of non-empty cells
+	AggregateDistinct                  // number of distinct non-empty cells
+)
+
+// WithAggregates overrides [Aggregated]. Aggregates are positional, one per
+// column, like [WithAlignments].
+func WithAggregates(aggs ...Aggregate) Option {
+	return func(o *options) { o.aggregates = aggs }
+}
+
+func (o *options) aggregatesFor(v any) []Aggregate {
+	if o.aggregates != nil {
+		return o.aggregates
+	}
+	if o.aggregateSource != nil {
+		return o.aggregateSource
+	}
+	if o.rowSource != nil {
+		// Aggregates() indexes the item's fields; with no aggregates in the
+		// spec, nothing lines up with the columns the spec picked.
+		return nil
+	}
+	if a, ok := v.(Aggregated); ok {
+		return a.Aggregates()
+	}
+	return nil
+}
+
+// footerOf returns v's [Footered] footer projected onto cols, the base that
+// aggregates are written into.
+func footerOf(v any, cols []int) []string {
+	if f, ok := v.(Footered); ok {
+		return pick(f.Footer(), cols)
+	}
+	return nil
+}
+
+// aggregator accumulates column aggregates one row at a time, so streaming
+// output can compute them without holding the rows. A nil aggregator
+// aggregates nothing.
+type aggregator struct {
+	aggs []Aggregate
+	cols []aggregateState
+}
+
+type aggregateState struct {
+	count    int // non-empty cells
+	distinct map[string]struct{}
+
+	// Numeric aggregates use cells that parse as a quantity of the same
+	// kind as the first one.
+	n        int
+	sum      float64
+	kind     quantityKind
+	binary   bool // byte sizes written in IEC units, such as GiB
+	percent  bool
+	min, max string
+	lo, hi   float64
+}
+
+// newAggregator returns an aggregator for aggs, or nil when aggs has no
+// aggregates.
+func newAggregator(aggs []Aggregate) *aggregator {
+	if !slices.ContainsFunc(aggs, func(a Aggregate) bool { return a != AggregateNone }) {
+		return nil
+	}
+	return &aggregator{aggs: aggs, cols: make([]aggregateState, len(aggs))}
+}
+
+func (a *aggregator) add(row []string) {
+	if a == nil {
+		return
+	}
+	for i, agg := range a.aggs {
+		cell := strings.TrimSpace(at(row, i))
+		if agg == AggregateNone || cell == "" {
+			continue
+		}
+		st := &a.cols[i]
+		st.count++
+		switch agg {
+		case AggregateCount:
+		case AggregateDistinct:
+			if st.distinct == nil {
+				st.distinct = map[string]struct{}{}
+			}
+			st.distinct[cell] = struct{}{}
+		default:
+			st.addQuantity(cell)
+		}
+	}
+}
+
+func (st *aggregateState) addQuantity(cell string) {
+	q, ok := parseQuantity(cell)
+	if !ok || (st.n > 0 && q.kind != st.kind) {
+		return
+	}
+	if st.n == 0 {
+		st.kind = q.kind
+		st.binary = strings.Contains(strings.ToLower(strings.TrimLeft(cell, "+-.,0123456789 ")), "i")
+		st.percent = strings.HasSuffix(cell, "%")
+		st.min, st.lo, st.max, st.hi = cell, q.value, cell, q.value
+	}
+	if q.value < st.lo {
+		st.min, st.lo = cell, q.value
+	}
+	if q.value > st.hi {
+		st.max, st.hi = cell, q.value
+	}
+	st.n++
+	st.sum += q.value
+}
+
+// footer returns base with each aggregated column replaced by its result.
+func (a *aggregator) footer(base []string) []string {
+	if a == nil {
+		return base
+	}
+	footer := make([]string, max(len(base), len(a.aggs)))
+	copy(footer, base)
+	for i, agg := range a.aggs {
+		if agg != AggregateNone {
+			footer[i] = a.cols[i].result(agg)
+		}
+	}
+	return footer
+}
+
+func (st *aggregateState) result(agg Aggregate) string {
+	switch agg {
+	case AggregateCount:
+		return strconv.Itoa(st.count)
+	case AggregateDistinct:
+		return strconv.Itoa(len(st.distinct))
+	}
+	if st.n == 0 {
+		return ""
+	}
+	switch agg {
+	case AggregateMin:
+		return st.min
+	case AggregateMax:
+		return st.max
+	case AggregateAvg:
+		return st.format(st.sum / float64(st.n))
+	default:
+		return st.format(st.sum)
+	}
+}
+
+// format renders v in the kind and units of the column's values.
+func (st *aggregateState) format(v float64) string {
+	switch st.kind {
+	case quantityDuration:
+		// Averages rarely land on a round duration; round to milliseconds
+		// from a second up and to microseconds below it, so "346.667ms"
+		// rather than "346.666667ms".
+		d := time.Duration(math.Round(v))
+		if d.Abs() >= time.Second {
+			return d.Round(time.Millisecond).String()
+		}
+		return d.Round(time.Microsecond).String()
+	case quantityBytes:
+		return formatBytes(v, st.binary)
+	}
+	s := formatDecimal(v)
+	if st.percent {
+		s += "%"
+	}
+	return s
+}
+
+// formatBytes renders a byte count in the largest unit that keeps it at
+// least 1, in IEC (KiB, MiB) or SI (KB, MB) units.
+func formatBytes(v float64, binary bool) string {
+	units, base := []string{"B", "KB", "MB", "GB", "TB", "PB"}, 1000.0
+	if binary {
+		units, base = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}, 1024
+	}
+	i := 0
+	for math.Abs(v) >= base && i < len(units)-1 {
+		v /= base
+		i++
+	}
+	return formatDecimal(v) + " " + units[i]
+}
+
+// formatDecimal renders v with at most two decimal places.
+func formatDecimal(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
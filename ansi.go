@@ -0,0 +1,166 @@
+package fmter
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Cells may carry ANSI escape sequences, such as SGR colors or OSC 8
+// hyperlinks, from the libraries that produced them. The helpers below treat
+// those sequences as zero-width so they neither count towards column widths
+// nor get cut in half.
+
+const (
+	sgrReset  = "\x1b[0m"
+	linkClose = "\x1b]8;;\x1b\\"
+)
+
+// escapeLen returns the length of the escape sequence at the start of s, or 0
+// when s does not start with one. CSI sequences end at their final byte and
+// OSC sequences at BEL or ST; other escapes are two bytes long.
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != '\x1b' {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+		return len(s)
+	case ']':
+		for i := 2; i < len(s); i++ {
+			if s[i] == '\a' {
+				return i + 1
+			}
+			if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+		return len(s)
+	}
+	return 2
+}
+
+// displayWidth returns the number of terminal columns s occupies, ignoring
+// escape sequences.
+func displayWidth(s string) int {
+	if !strings.Contains(s, "\x1b") {
+		return runewidth.StringWidth(s)
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		sb.WriteByte(s[i])
+		i++
+	}
+	return runewidth.StringWidth(sb.String())
+}
+
+// cutWidth splits s after at most width columns of visible text. Escape
+// sequences stay with the text that follows them, so a color opened at the
+// cut starts the rest.
+func cutWidth(s string, width int) (head, rest string) {
+	w, end := 0, 0
+	for i := 0; i < len(s); {
+		if n := escapeLen(s[i:]); n > 0 {
+			i += n
+			continue
+		}
+		j := i + 1
+		for j < len(s) && escapeLen(s[j:]) == 0 {
+			j++
+		}
+		run := s[i:j]
+		fit := runewidth.Truncate(run, width-w, "")
+		if len(fit) < len(run) {
+			if fit != "" {
+				end = i + len(fit)
+			}
+			return s[:end], s[end:]
+		}
+		w += runewidth.StringWidth(run)
+		i, end = j, j
+	}
+	return s, ""
+}
+
+// truncateWidth shortens s to at most width columns, ending it with tail
+// and closing any style or hyperlink left open by the cut.
+func truncateWidth(s string, width int, tail string) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	head, _ := cutWidth(s, width-runewidth.StringWidth(tail))
+	var st ansiState
+	st.scan(head)
+	return head + tail + st.close()
+}
+
+// ansiState tracks the SGR attributes and OSC 8 hyperlink in effect after a
+// run of text.
+type ansiState struct {
+	sgr  []string
+	link string
+}
+
+func (st *ansiState) scan(s string) {
+	for i := 0; i < len(s); i++ {
+		n := escapeLen(s[i:])
+		if n == 0 {
+			continue
+		}
+		seq := s[i : i+n]
+		i += n - 1
+		switch {
+		case seq == sgrReset || seq == "\x1b[m":
+			st.sgr = nil
+		case strings.HasPrefix(seq, "\x1b[") && strings.HasSuffix(seq, "m"):
+			st.sgr = append(st.sgr, seq)
+		case strings.HasPrefix(seq, "\x1b]8;"):
+			// ESC ] 8 ; params ; URI ST, where an empty URI ends the link.
+			body := strings.TrimSuffix(strings.TrimSuffix(seq[4:], "\a"), "\x1b\\")
+			if _, uri, _ := strings.Cut(body, ";"); uri != "" {
+				st.link = seq
+			} else {
+				st.link = ""
+			}
+		}
+	}
+}
+
+// open returns the sequences that restore the state at the start of a line.
+func (st *ansiState) open() string {
+	return strings.Join(st.sgr, "") + st.link
+}
+
+// close returns the sequences that end the state at the end of a line.
+func (st *ansiState) close() string {
+	var s string
+	if len(st.sgr) > 0 {
+		s += sgrReset
+	}
+	if st.link != "" {
+		s += linkClose
+	}
+	return s
+}
+
+// carryStyles closes the styles left open at the end of each line and
+// reopens them at the start of the next, so each line of a multi-line cell
+// is styled on its own and never bleeds into the borders.
+func carryStyles(lines []string) []string {
+	var st ansiState
+	for i, line := range lines {
+		open := st.open()
+		st.scan(line)
+		lines[i] = open + line + st.close()
+	}
+	return lines
+}
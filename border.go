@@ -0,0 +1,99 @@
+package fmter
+
+import "sync"
+
+// BorderSet is the set of characters a bordered Table is drawn with, and
+// which of its lines to draw. The built-in sets are returned by
+// [BorderStyle.Chars]; register a set of your own with [RegisterBorder], or
+// pass one with [WithBorderSet].
+type BorderSet struct {
+	TopLeft, TopRight, BottomLeft, BottomRight string
+	Horizontal, Vertical                       string
+	TopTee, BottomTee, LeftTee, RightTee       string
+	Cross                                      string
+
+	NoFrame   bool // omit the outer frame, leaving the lines inside the table
+	NoColumns bool // omit the vertical lines between columns
+	RowLines  bool // draw a line between every two data rows
+}
+
+var borderSets = map[BorderStyle]BorderSet{
+	BorderRounded: {
+		TopLeft: "╭", TopRight: "╮", BottomLeft: "╰", BottomRight: "╯",
+		Horizontal: "─", Vertical: "│",
+		TopTee: "┬", BottomTee: "┴", LeftTee: "├", RightTee: "┤",
+		Cross: "┼",
+	},
+	BorderASCII: {
+		TopLeft: "+", TopRight: "+", BottomLeft: "+", BottomRight: "+",
+		Horizontal: "-", Vertical: "|",
+		TopTee: "+", BottomTee: "+", LeftTee: "+", RightTee: "+",
+		Cross: "+",
+	},
+	BorderHeavy: {
+		TopLeft: "┏", TopRight: "┓", BottomLeft: "┗", BottomRight: "┛",
+		Horizontal: "━", Vertical: "┃",
+		TopTee: "┳", BottomTee: "┻", LeftTee: "┣", RightTee: "┫",
+		Cross: "╋",
+	},
+	BorderDouble: {
+		TopLeft: "╔", TopRight: "╗", BottomLeft: "╚", BottomRight: "╝",
+		Horizontal: "═", Vertical: "║",
+		TopTee: "╦", BottomTee: "╩", LeftTee: "╠", RightTee: "╣",
+		Cross: "╬",
+	},
+}
+
+var (
+	bordersMu     sync.RWMutex
+	customBorders []BorderSet
+)
+
+// firstCustomBorder is the BorderStyle of the first registered set.
+const firstCustomBorder = BorderDouble + 1
+
+// RegisterBorder adds a custom border set and returns the BorderStyle that
+// selects it, for use with [WithBorder] and [Bordered] like a built-in
+// style. Register sets once, typically in a package-level var:
+//
+//	var BorderLight = fmter.RegisterBorder(fmter.BorderSet{
+//		TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+//		...
+//	})
+func RegisterBorder(set BorderSet) BorderStyle {
+	bordersMu.Lock()
+	defer bordersMu.Unlock()
+	customBorders = append(customBorders, set)
+	return firstCustomBorder + BorderStyle(len(customBorders)-1)
+}
+
+// Chars returns the border set b draws with, which can be modified and
+// passed to [RegisterBorder] or [WithBorderSet]. It is the zero BorderSet
+// for [BorderNone] and unknown styles.
+func (b BorderStyle) Chars() BorderSet {
+	if set, ok := borderSets[b]; ok {
+		return set
+	}
+	bordersMu.RLock()
+	defer bordersMu.RUnlock()
+	if i := int(b - firstCustomBorder); i >= 0 && i < len(customBorders) {
+		return customBorders[i]
+	}
+	return BorderSet{}
+}
+
+// WithBorderSet draws tables with set, overriding [Bordered]. Between it and
+// [WithBorder], the last one given wins.
+func WithBorderSet(set BorderSet) Option {
+	return func(o *options) { o.borderSet = &set }
+}
+
+// borderSetFor returns the border set to draw v's table with, or false when
+// the table has no borders.
+func (o *options) borderSetFor(v any) (BorderSet, bool) {
+	if o.borderSet != nil {
+		return *o.borderSet, true
+	}
+	b := o.borderFor(v)
+	return b.Chars(), b != BorderNone
+}
@@ -0,0 +1,71 @@
+package fmter
+
+import "html"
+
+// Style styles a single cell or row. Table output wraps each aligned cell in
+// Apply; HTML output adds Class to the element's class attribute. Either may
+// be empty.
+type Style struct {
+	Class string
+	Apply func(string) string
+}
+
+// column returns the index into the item's row of display column i, or -1
+// for the row number column.
+func (s *tableSpec) column(i int) int {
+	if s.numbered {
+		if i == 0 {
+			return -1
+		}
+		i--
+	}
+	if s.cols != nil {
+		return s.cols[i]
+	}
+	return i
+}
+
+// rowStyles returns the styles for item's displayed cells: base, the column
+// styles, with any [CellStyled] style in place of a column's and any
+// [RowStyled] style wrapped around every cell.
+func (s *tableSpec) rowStyles(item any, cells []string, base []func(string) string) []func(string) string {
+	cs, cellStyled := item.(CellStyled)
+	rs, rowStyled := item.(RowStyled)
+	if !cellStyled && !rowStyled {
+		return base
+	}
+	var row func(string) string
+	if rowStyled {
+		row = rs.RowStyle().Apply
+	}
+	styles := make([]func(string) string, len(base))
+	for i, style := range base {
+		if col := s.column(i); cellStyled && col >= 0 {
+			if cell := cs.CellStyle(col, at(cells, i)).Apply; cell != nil {
+				style = cell
+			}
+		}
+		styles[i] = chain(style, row)
+	}
+	return styles
+}
+
+// chain returns a style applying inner and then outer, either of which may
+// be nil.
+func chain(inner, outer func(string) string) func(string) string {
+	switch {
+	case outer == nil:
+		return inner
+	case inner == nil:
+		return outer
+	}
+	return func(s string) string { return outer(inner(s)) }
+}
+
+// classAttr returns a class attribute for class, or "" when it is empty.
+func classAttr(class string) string {
+	if class == "" {
+		return ""
+	}
+	return ` class="` + html.EscapeString(class) + `"`
+}
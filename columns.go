@@ -0,0 +1,71 @@
+package fmter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithColumns selects and orders the columns rendered by the row-based
+// formats (Table, CSV, TSV, Markdown, HTML). Names are matched
+// case-insensitively against the header from [Headed] or struct tags.
+// Per-column metadata ([Aligned], [Styled], [Truncated], [Wrapped],
+// [Footered]) follows the selected columns. Unknown names fail with
+// [ErrUnknownColumn].
+func WithColumns(names ...string) Option {
+	return func(o *options) { o.columns = names }
+}
+
+// columnsFor resolves the column selection against v's header. It returns
+// nil when no selection was requested.
+func (o *options) columnsFor(v any) ([]int, error) {
+	if o.columns == nil {
+		return nil, nil
+	}
+	header, _ := o.headerOf(v)
+	cols := make([]int, len(o.columns))
+	for i, name := range o.columns {
+		idx := columnIndex(header, name)
+		if idx < 0 {
+			if len(header) == 0 {
+				return nil, fmt.Errorf("%w: %q (%T has no header)", ErrUnknownColumn, name, v)
+			}
+			return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownColumn, name, strings.Join(header, ", "))
+		}
+		cols[i] = idx
+	}
+	return cols, nil
+}
+
+func columnIndex(header []string, name string) int {
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// pick returns s reordered by cols. Indexes past the end of s yield zero
+// values so short metadata slices stay aligned with the selected columns.
+// A nil s or nil cols returns s unchanged.
+func pick[E any](s []E, cols []int) []E {
+	if s == nil || cols == nil {
+		return s
+	}
+	out := make([]E, len(cols))
+	for i, c := range cols {
+		if c < len(s) {
+			out[i] = s[c]
+		}
+	}
+	return out
+}
+
+// at returns s[i], or the zero value when i is out of range.
+func at[E any](s []E, i int) E {
+	var zero E
+	if i >= 0 && i < len(s) {
+		return s[i]
+	}
+	return zero
+}
@@ -2,37 +2,45 @@ package fmter
 
 import (
 	"encoding/csv"
-	"fmt"
 	"io"
 )
 
-func writeCSV[T any](w io.Writer, items []T) error {
+func writeCSV[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
-	if _, ok := any(items[0]).(Rower); !ok {
-		return fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, CSV, items[0])
+	if err := checkItems(CSV, items, o); err != nil {
+		return err
 	}
-	cw := csv.NewWriter(w)
-	if d, ok := any(items[0]).(Delimited); ok {
-		cw.Comma = d.Delimiter()
+	cols, err := o.columnsFor(items[0])
+	if err != nil {
+		return err
 	}
-	if h, ok := any(items[0]).(Headed); ok {
-		if err := cw.Write(h.Header()); err != nil {
+	cw := csv.NewWriter(w)
+	cw.Comma = o.delimiterFor(items[0])
+	if header := o.flatHeaderFor(items[0]); header != nil {
+		if err := cw.Write(pick(header, cols)); err != nil {
 			return err
 		}
 	}
-	for _, item := range items {
-		if err := cw.Write(any(item).(Rower).Row()); err != nil {
+	agg := newAggregator(pick(o.aggregatesFor(items[0]), cols))
+	for i, item := range items {
+		row := pick(o.rowAt(i, item), cols)
+		agg.add(row)
+		if err := cw.Write(row); err != nil {
 			return err
 		}
 	}
+	if agg != nil {
+		return cw.WriteAll([][]string{agg.footer(footerOf(items[0], cols))})
+	}
 	cw.Flush()
 	return cw.Error()
 }
 
-func writeCSVRow(w io.Writer, row []string) error {
+func writeCSVRow(w io.Writer, row []string, comma rune) error {
 	cw := csv.NewWriter(w)
+	cw.Comma = comma
 	if err := cw.Write(row); err != nil {
 		return err
 	}
@@ -0,0 +1,249 @@
+package fmter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	customColumnsPrefix     = "custom-columns="
+	customColumnsFilePrefix = "custom-columns-file="
+)
+
+// CustomColumns returns a Format that renders a [Table] from field paths, in
+// the style of kubectl. The spec is a comma-separated list of HEADER:path
+// pairs:
+//
+//	fmter.CustomColumns("NAME:.name,PORT:.spec.port")
+//
+// Paths are evaluated against the JSON representation of each item, so they
+// use JSON field names (matched case-insensitively as a fallback) and work on
+// arbitrary structs and maps without [Rower]. Array elements are selected
+// with [n]. Missing values render as "<none>".
+func CustomColumns(spec string) Format {
+	return Format(customColumnsPrefix + spec)
+}
+
+// CustomColumnsFile returns a Format like [CustomColumns] that reads its spec
+// from a file. The first line holds the headers and the second line the
+// matching paths, both separated by whitespace.
+func CustomColumnsFile(path string) Format {
+	return Format(customColumnsFilePrefix + path)
+}
+
+func isCustomColumns(f Format) bool {
+	return strings.HasPrefix(string(f), customColumnsPrefix) || strings.HasPrefix(string(f), customColumnsFilePrefix)
+}
+
+type customColumn struct {
+	header string
+	path   []pathStep
+}
+
+type pathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// customColumnsTable resolves a custom-columns format into options that
+// render it as a Table.
+func customColumnsTable(f Format, o *options) (*options, error) {
+	var cols []customColumn
+	var err error
+	if path, ok := strings.CutPrefix(string(f), customColumnsFilePrefix); ok {
+		cols, err = readCustomColumnsFile(path)
+	} else {
+		cols, err = parseCustomColumns(strings.TrimPrefix(string(f), customColumnsPrefix))
+	}
+	if err != nil {
+		return nil, err
+	}
+	co := *o
+	co.headerSource = make([]string, len(cols))
+	for i, c := range cols {
+		co.headerSource[i] = c.header
+	}
+	co.rowCheck = func(v any) error {
+		_, err := jsonValue(v)
+		return err
+	}
+	co.rowSource = func(v any) []string {
+		doc, _ := jsonValue(v) // rowCheck reports items that can't be encoded
+		row := make([]string, len(cols))
+		for i, c := range cols {
+			row[i] = formatPathValue(evalPath(doc, c.path))
+		}
+		return row
+	}
+	return &co, nil
+}
+
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, fmt.Errorf("%w: custom-columns spec is empty", ErrInvalidFormat)
+	}
+	var cols []customColumn
+	for part := range strings.SplitSeq(spec, ",") {
+		header, expr, ok := strings.Cut(part, ":")
+		if !ok || strings.TrimSpace(header) == "" {
+			return nil, fmt.Errorf("%w: custom-columns entry %q must be HEADER:path", ErrInvalidFormat, part)
+		}
+		path, err := parsePath(expr)
+		if err != nil {
+			return nil, err
+		}
+		cols = append(cols, customColumn{header: strings.TrimSpace(header), path: path})
+	}
+	return cols, nil
+}
+
+func readCustomColumnsFile(name string) ([]customColumn, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: custom-columns-file: %w", ErrInvalidFormat, err)
+	}
+	var lines []string
+	for line := range strings.Lines(string(data)) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("%w: custom-columns-file %s must have a header line and a path line", ErrInvalidFormat, name)
+	}
+	headers, paths := strings.Fields(lines[0]), strings.Fields(lines[1])
+	if len(headers) != len(paths) {
+		return nil, fmt.Errorf("%w: custom-columns-file %s has %d headers but %d paths", ErrInvalidFormat, name, len(headers), len(paths))
+	}
+	cols := make([]customColumn, len(headers))
+	for i := range headers {
+		path, err := parsePath(paths[i])
+		if err != nil {
+			return nil, err
+		}
+		cols[i] = customColumn{header: headers[i], path: path}
+	}
+	return cols, nil
+}
+
+// parsePath parses a field path such as ".spec.ports[0].port". Surrounding
+// braces, as in "{.metadata.name}", are accepted.
+func parsePath(expr string) ([]pathStep, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+	if !strings.HasPrefix(s, ".") {
+		return nil, fmt.Errorf("%w: path %q must start with '.'", ErrInvalidFormat, expr)
+	}
+	var steps []pathStep
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end > 0 {
+				steps = append(steps, pathStep{key: s[:end]})
+			}
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w: path %q has an unclosed '['", ErrInvalidFormat, expr)
+			}
+			n, err := strconv.Atoi(s[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("%w: path %q has an invalid index", ErrInvalidFormat, expr)
+			}
+			steps = append(steps, pathStep{index: n, isIndex: true})
+			s = s[end+1:]
+		default:
+			return nil, fmt.Errorf("%w: path %q is malformed", ErrInvalidFormat, expr)
+		}
+	}
+	return steps, nil
+}
+
+// jsonValue returns the generic JSON representation of v (maps, slices,
+// strings, json.Number, bools, and nil), or an error when v can't be
+// encoded as JSON.
+func jsonValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	_ = dec.Decode(&out) // Marshal output always decodes
+	return out, nil
+}
+
+func evalPath(v any, path []pathStep) any {
+	for _, step := range path {
+		switch node := v.(type) {
+		case map[string]any:
+			if step.isIndex {
+				return nil
+			}
+			v, _ = lookupKey(node, step.key)
+		case []any:
+			if !step.isIndex {
+				return nil
+			}
+			i := step.index
+			if i < 0 {
+				i += len(node)
+			}
+			if i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// lookupKey returns the value of key in m, matching case-insensitively when
+// no key matches exactly. Of several keys differing only in case, the first
+// in sorted order wins, so the result doesn't depend on map order.
+func lookupKey(m map[string]any, key string) (any, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	match, found := "", false
+	for k := range m {
+		if strings.EqualFold(k, key) && (!found || k < match) {
+			match, found = k, true
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return m[match], true
+}
+
+func formatPathValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "<none>"
+	case string:
+		return val
+	case []any:
+		parts := make([]string, len(val))
+		for i, e := range val {
+			parts[i] = formatPathValue(e)
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -1,21 +1,37 @@
 // Package fmter renders structured data in multiple output formats.
 //
 // Supported formats are JSON, YAML, CSV, Table, Markdown, List, ENV, Plain,
-// TSV, JSONL, HTML, and GoTemplate. The central entry points are [Write] and
-// [Marshal], which accept a [Format] constant and variadic items of any type.
-// JSON, YAML, Plain, and JSONL work on any value; other formats require the
-// items to implement specific interfaces.
+// TSV, JSONL, HTML, Vertical, Tree, and GoTemplate. The central entry points
+// are [Write] and [Marshal], which accept a [Format] constant and variadic
+// items of any type. JSON, YAML, Plain, and JSONL work on any value; other
+// formats require the items to implement specific interfaces.
 //
 // # Interface Design
 //
 // The package uses a layered interface design. A minimal interface unlocks a
 // format, and optional interfaces enhance the rendering:
 //
-//   - [Rower] → CSV, Table, Markdown, TSV, HTML (row data)
-//   - [Headed] → adds column headers to CSV, Table, Markdown, TSV, HTML
+//   - [Rower] → CSV, Table, Markdown, TSV, HTML, Vertical (row data)
+//   - [Headed] → adds column headers to CSV, Table, Markdown, TSV, HTML,
+//     Vertical
 //   - [Lister] → List format
 //   - [Mappable] → ENV format
 //
+// # Struct Tags
+//
+// Items that do not implement [Rower] can still be rendered as rows when they
+// are structs with fmter tags. Rows, headers, alignments, max widths, and fmt
+// verbs are derived from the tags; reflection results are cached per type:
+//
+//	type Service struct {
+//		Name string  `fmter:"Name"`
+//		Port int     `fmter:"Port,align=right,width=6"`
+//		Load float64 `fmter:"Load,format=%.2f"`
+//	}
+//
+// Explicit interfaces ([Headed], [Aligned], [Truncated]) take precedence over
+// the tags.
+//
 // Use [IsSupported] to check at runtime whether a type implements the required
 // interfaces for a given format:
 //
@@ -34,6 +50,7 @@
 //
 //   - [Headed] — header row
 //   - [Delimited] — custom field delimiter (default comma)
+//   - [Aggregated] — a final row of column aggregates
 //
 // # TSV
 //
@@ -50,18 +67,60 @@
 //   - [Bordered] — border style (default [BorderRounded])
 //   - [Aligned] — per-column alignment
 //   - [Footered] — footer row
+//   - [Aggregated] — footer cells computed over every row (sum, avg, ...)
 //   - [Numbered] — row number column
 //   - [Captioned] — line below the table
 //   - [Truncated] — max column widths with "..." truncation
 //   - [Styled] — per-column style functions (e.g., ANSI colors)
+//   - [CellStyled], [RowStyled] — per-cell and per-row styles chosen by value
 //   - [Grouped] — separator between groups of rows
+//   - [GroupHeaded] — a header row naming each group
+//   - [Subtotaled] — per-group aggregates below each group
+//   - [HeaderGrouped] — a header row titling runs of adjacent columns
+//   - [Spanned] — cells spanning several columns
 //   - [Paged] — repeat header every N rows
 //   - [Wrapped] — multi-line cells with per-column wrap widths
+//   - [Fitted] — how columns shrink to fit the maximum width
+//   - [Themed] — colors for borders, header, title, footer, and caption
+//
+// When writing to a terminal, tables are fitted to its width (COLUMNS, else
+// the size the terminal reports). [WithMaxWidth] sets the width explicitly,
+// or removes the limit with 0. Columns shrink in [Fit] priority order, widest
+// first, and are truncated or wrapped:
+//
+//	fmter.WriteWith(os.Stdout, fmter.Table, []fmter.Option{fmter.WithMaxWidth(100)}, items...)
+//
+// Wrapping breaks lines at spaces, splitting only words too wide for the
+// column. Cells containing newlines render as multi-line cells whether or
+// not the item implements [Wrapped]. Cells may already contain ANSI colors
+// or OSC 8 hyperlinks: escape sequences take no width, are never cut in
+// half, and styles are closed and reopened around truncation and line
+// breaks.
+//
+// [RegisterBorder] adds border styles of your own, from a [BorderSet] of
+// characters that can also drop the outer frame or the lines between
+// columns, or draw a line between every row. [WithBorderSet] passes a set
+// for a single write.
+//
+// A [Theme] such as [ThemeDark], [ThemeLight], or [ThemeMonochrome] colors
+// everything but the data cells. It is applied only when writing to a
+// terminal and NO_COLOR is unset, or when FORCE_COLOR is set to anything
+// but 0 or false; [WithColor] overrides the detection:
+//
+//	fmter.WriteWith(os.Stdout, fmter.Table, []fmter.Option{fmter.WithTheme(fmter.ThemeDark)}, items...)
 //
 // # Markdown
 //
 // Requires [Rower] and [Headed]. Renders a GitHub-flavored Markdown table.
-// Implement [Aligned] to set column alignment markers.
+// Implement [Aligned] to set column alignment markers, and [Footered] or
+// [Aggregated] to end the table with a bold footer row. [Titled] adds a
+// heading above the table, [Captioned] a paragraph below it, and [Numbered]
+// a row number column. [Grouped] items get bold group header and subtotal
+// rows. [HeaderGrouped] titles prefix the headers of their columns.
+//
+// Cells are escaped, so pipes and markup characters in values appear as
+// written. Table cells can't span lines, so multi-line cells, and cells
+// wrapped at their [Wrapped] width, are joined with <br> tags.
 //
 // # HTML
 //
@@ -69,8 +128,39 @@
 //
 //   - [Headed] → <thead>
 //   - [Titled] → <caption>
-//   - [Footered] → <tfoot>
+//   - [Footered], [Aggregated] → <tfoot>
 //   - [Aligned] → text-align style on <td>/<th>
+//   - [CellStyled], [RowStyled] → class attribute on <td>/<tr>
+//   - [Grouped] → a <tbody> per group, with [GroupHeaded] and [Subtotaled]
+//     rows
+//   - [HeaderGrouped], [Spanned] → colspan on <th>/<td>
+//
+// # Vertical
+//
+// Requires [Rower]. Renders each item as a record of "Header: value" lines
+// with aligned labels, like psql's expanded display, for rows too wide for a
+// Table. Records are separated by "-[ RECORD n ]" lines; without [Headed],
+// values are labeled with their column number. Optional interfaces:
+//
+//   - [Titled] → a line above the first record
+//   - [Styled] → per-column value styles
+//   - [Wrapped] → long values wrap onto indented continuation lines
+//
+// # Tree
+//
+// Requires [Parent]. Renders items and their children, recursively, with
+// "├──" and "└──" connectors, like tree(1). A node is labeled by the first
+// cell of its row, with the rest of the row in aligned columns, or by its
+// [fmt.Stringer] output when it has no row. Optional interfaces:
+//
+//   - [Headed] → a header line over the columns
+//   - [Aligned] → per-column alignment
+//   - [Bordered] → connector style: ASCII, heavy, double, or indentation only
+//     with [BorderNone]
+//
+// With [WithTree], JSON, JSONL, and YAML nest a Parent's children under a
+// "children" key. A cycle in Children() is an error wrapping
+// [ErrInvalidFormat].
 //
 // # List
 //
@@ -101,18 +191,117 @@
 //
 //	fmter.Write(os.Stdout, fmter.GoTemplate("{{.Name}}: {{.Age}}"), items...)
 //
+// # JSONPath
+//
+// Use [JSONPath] for kubectl-style JSONPath templates evaluated against the
+// JSON representation of each item, with filters, wildcards, recursive
+// descent, and {range}...{end}:
+//
+//	fmter.Write(os.Stdout, fmter.JSONPath(`{.name}{"\t"}{.ports[?(@.port > 1024)].port}`), items...)
+//
+// No List wraps the items, so write {.name} where kubectl would write
+// {.items[*].name}.
+//
+// # Options
+//
+// Every metadata interface can be overridden per call with an [Option], so
+// CLI flags win over type defaults. Use [WriteWith] and [MarshalWith], or
+// pass options to [WriteIter] and [WriteChan]:
+//
+//	opts := []fmter.Option{fmter.WithBorder(fmter.BorderASCII), fmter.WithNoHeaders()}
+//	fmter.WriteWith(os.Stdout, fmter.Table, opts, items...)
+//
+// [WithColumns] selects and reorders the columns of the row-based formats by
+// header name (case-insensitive), keeping per-column metadata aligned:
+//
+//	fmter.WriteWith(os.Stdout, fmter.Table, []fmter.Option{fmter.WithColumns("port", "name")}, items...)
+//
+// # Sorting
+//
+// Sorting is opt-in. [WithSort] orders items by the column declared with
+// [Sorted]; [WithSortBy] takes explicit header names, as from a --sort-by
+// flag, with "-" for descending order:
+//
+//	fmter.WriteWith(os.Stdout, fmter.Table, []fmter.Option{fmter.WithSortBy("name,-port")}, items...)
+//
+// Numbers, durations, and byte sizes compare by value rather than as text.
+// Sorting applies to every format, including JSON and YAML, when the items
+// provide rows and a header. [WriteIter] collects all items before writing
+// when sorting is enabled.
+//
+// # Custom Columns
+//
+// [CustomColumns] builds a Table from kubectl-style field paths, evaluated
+// against the JSON representation of each item, so no [Rower] is needed.
+// [CustomColumnsFile] reads the spec from a file. Both are recognized by
+// [ParseFormat] and still honor [Bordered], [Aligned], and the other table
+// interfaces:
+//
+//	fmter.Write(os.Stdout, fmter.CustomColumns("NAME:.name,PORT:.spec.port"), items...)
+//
+// # Mixed Items
+//
+// Every item is checked against the format's required interfaces, so slices
+// of an interface type may hold several concrete types. By default the
+// header, alignment and other table settings come from the first item.
+// [WithMixed] changes that for tabular formats: [MixedUnion] renders one
+// table over the union of every type's [Headed] columns, leaving missing
+// cells blank, with alignments, widths, and aggregates following each type's
+// columns, and [MixedSplit] renders one table per type, separated by a blank
+// line:
+//
+//	fmter.WriteWith(os.Stdout, fmter.Table, []fmter.Option{fmter.WithMixed(fmter.MixedSplit)}, resources...)
+//
 // # Streaming
 //
 // [WriteIter] and [WriteChan] support streaming output for iterator and
 // channel sources. Formats that render items independently (Plain, JSONL,
-// CSV, TSV, GoTemplate) write each item as it arrives. Formats that need
-// all data for layout (Table, Markdown, HTML) collect items first.
+// CSV, TSV, JSONPath, Vertical) write each item as it arrives. Formats that
+// need all data for layout (Table, Markdown, HTML, Tree) collect items first.
+//
+// [WithStreamingTable] renders Table in bounded memory instead: widths come
+// from the header, [Truncated] and [Wrapped] hints, and the first rows, and
+// later rows that do not fit are truncated or start a new table:
+//
+//	fmter.WriteIter(os.Stdout, fmter.Table, seq, fmter.WithStreamingTable(100, fmter.OverflowRepaginate))
+//
+// [WriteIter2] accepts an [iter.Seq2] of items and errors for producers that
+// can fail mid-stream. It stops at the first error, closes off the partial
+// output so it stays well-formed, and returns the error wrapped with the
+// index of the failed item.
+//
+// [WriteIterContext], [WriteIter2Context], and [WriteChanContext] also stop
+// when a [context.Context] is canceled or its deadline passes, close off the
+// output in the same way, and return ctx.Err(). WriteChanContext stops even
+// while waiting on a channel that is never closed.
 //
 // # Formatter
 //
 // Implement [Formatter] for per-item control. If Format returns non-nil
-// bytes, they are written directly; returning (nil, nil) falls through to
-// default rendering.
+// bytes, they take the item's place in the output; returning (nil, nil) falls
+// through to default rendering. Output keeps the input order, and the bytes
+// take part in structured formats: JSON and YAML embed them as the item's
+// array element, and the tabular formats read them as the item's row, a CSV
+// record for CSV and tab-separated cells otherwise, so widths, columns and
+// borders still apply:
+//
+//	func (s Service) Format(f fmter.Format) ([]byte, error) {
+//		if f == fmter.Table && s.Redacted {
+//			return []byte(s.Name + "\t<redacted>"), nil
+//		}
+//		return nil, nil
+//	}
+//
+// # Custom Formats
+//
+// Use [RegisterFormat] to add a third-party format. Registered formats are
+// recognized by [ParseFormat] and [Formats] and rendered by [Write],
+// [Marshal], [WriteIter], and [WriteChan] like the built-ins:
+//
+//	fmter.RegisterFormat("xml", renderXML,
+//		fmter.WithStreamRenderer(streamXML),
+//		fmter.WithSupports(func(v any) bool { _, ok := v.(XMLer); return ok }),
+//	)
 //
 // # Format Selection
 //
@@ -127,6 +316,15 @@
 // The package exports sentinel errors for programmatic handling:
 //
 //   - [ErrUnsupportedFormat] — unknown format string
-//   - [ErrMissingInterface] — items don't implement the required interface
-//   - [ErrInvalidTemplate] — invalid go-template syntax
+//   - [ErrMissingInterface] — items don't implement the required interface;
+//     the error is a [*MissingInterfaceError] listing what is missing, the
+//     index of the offending item, and which formats the type does support
+//   - [ErrInvalidTemplate] — invalid go-template or jsonpath syntax, or a
+//     jsonpath reading .items from an item that has none
+//   - [ErrInvalidFormat] — malformed custom-columns spec, [RegisterFormat]
+//     called without a name or renderer, [Formatter] output that is not
+//     valid for the format, or a cycle in [Parent] children
+//   - [ErrFormatExists] — [RegisterFormat] called with a name already in use
+//   - [ErrUnknownColumn] — [WithColumns] or [WithSortBy] names a column not
+//     in the header
 package fmter
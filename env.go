@@ -5,23 +5,16 @@ import (
 	"io"
 )
 
-func writeENV[T any](w io.Writer, items []T) error {
+func writeENV[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
-	if _, ok := any(items[0]).(Mappable); !ok {
-		return fmt.Errorf("%w: format %q requires Mappable, not implemented by %T", ErrMissingInterface, ENV, items[0])
-	}
-	export := false
-	if e, ok := any(items[0]).(Exported); ok {
-		export = e.Export()
-	}
-	quoted := false
-	if q, ok := any(items[0]).(Quoted); ok {
-		quoted = q.Quote()
+	if err := checkItems(ENV, items, o); err != nil {
+		return err
 	}
+	quoted := o.quoteFor(items[0])
 	prefix := ""
-	if export {
+	if o.exportFor(items[0]) {
 		prefix = "export "
 	}
 	for i, item := range items {
@@ -30,6 +23,12 @@ func writeENV[T any](w io.Writer, items []T) error {
 				return err
 			}
 		}
+		if data, ok := o.formattedAt(i); ok {
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			continue
+		}
 		for _, kv := range any(item).(Mappable).Pairs() {
 			var err error
 			if quoted {
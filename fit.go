@@ -0,0 +1,104 @@
+package fmter
+
+import (
+	"io"
+	"slices"
+)
+
+// fitMinWidth is the narrowest a column shrinks to when its [Fit] sets no
+// minimum.
+const fitMinWidth = 3
+
+// Fit describes how a Table column shrinks when the table is wider than its
+// maximum width. See [Fitted] and [WithMaxWidth].
+type Fit struct {
+	// Priority orders shrinking: columns with a lower priority shrink to
+	// their minimum before any column with a higher priority shrinks.
+	// Within a priority, the widest column shrinks first.
+	Priority int
+	// Wrap wraps the column's cells at the shrunk width instead of
+	// truncating them. Columns with a [Wrapped] width always wrap.
+	Wrap bool
+	// Min is the narrowest width the column shrinks to. Zero means 3.
+	Min int
+}
+
+// WithMaxWidth limits the total width of Table output, shrinking columns as
+// their [Fitted] entries describe. Without it, the limit is the terminal
+// width when writing to a terminal (the COLUMNS environment variable, else
+// the size the terminal reports) and there is none otherwise. A width of
+// zero or less removes the limit.
+func WithMaxWidth(width int) Option {
+	return func(o *options) { o.maxWidth = &width }
+}
+
+func (o *options) tableWidthFor(w io.Writer) int {
+	if o.maxWidth != nil {
+		return max(*o.maxWidth, 0)
+	}
+	return terminalWidth(w)
+}
+
+// fit shrinks widths until the table is no wider than s.width, and returns
+// the widths to draw with and the widths to wrap cells at.
+func (s *tableSpec) fit(widths []int) ([]int, []int) {
+	over := s.tableWidth(widths) - s.width
+	if s.width <= 0 || over <= 0 {
+		return widths, s.wrapWidths
+	}
+	fitted := slices.Clone(widths)
+	mins := make([]int, len(widths))
+	var priorities []int
+	for i, width := range widths {
+		f := at(s.fits, i)
+		mins[i] = min(width, fitMinWidth)
+		if f.Min > 0 {
+			mins[i] = min(width, f.Min)
+		}
+		if s.numbered && i == 0 {
+			mins[i] = width
+		}
+		priorities = append(priorities, f.Priority)
+	}
+	slices.Sort(priorities)
+	for _, p := range slices.Compact(priorities) {
+		for over > 0 {
+			widest := -1
+			for i, width := range fitted {
+				if at(s.fits, i).Priority == p && width > mins[i] && (widest < 0 || width > fitted[widest]) {
+					widest = i
+				}
+			}
+			if widest < 0 {
+				break
+			}
+			fitted[widest]--
+			over--
+		}
+	}
+
+	wraps := make([]int, len(widths))
+	copy(wraps, s.wrapWidths)
+	for i, width := range fitted {
+		if width < widths[i] && (at(s.fits, i).Wrap || wraps[i] > 0) {
+			wraps[i] = width
+		}
+	}
+	return fitted, wraps
+}
+
+// tableWidth returns the printed width of a table with the given column
+// widths.
+func (s *tableSpec) tableWidth(widths []int) int {
+	if s.bordered {
+		if s.borders.NoFrame {
+			return tableInnerWidth(widths, s.borders)
+		}
+		return tableInnerWidth(widths, s.borders) + 2
+	}
+	n := 2 * max(len(widths)-1, 0)
+	for _, w := range widths {
+		n += w
+	}
+	return n
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -13,8 +15,50 @@ var (
 	ErrUnsupportedFormat = errors.New("unsupported format")
 	ErrMissingInterface  = errors.New("missing required interface")
 	ErrInvalidTemplate   = errors.New("invalid template")
+	ErrInvalidFormat     = errors.New("invalid format")
+	ErrFormatExists      = errors.New("format already registered")
+	ErrUnknownColumn     = errors.New("unknown column")
 )
 
+// MissingInterfaceError reports that items do not implement the interfaces a
+// format requires. It matches [ErrMissingInterface] with [errors.Is]; use
+// [errors.As] to suggest one of the Supported formats instead.
+type MissingInterfaceError struct {
+	Format    Format   // the requested format
+	Missing   []string // names of the missing interfaces, such as "Rower"
+	Type      string   // Go type of the offending item, as printed by %T
+	Index     int      // index of the first item that lacks them, or -1 for a whole type
+	Supported []Format // formats the type does support, in [Formats] order
+}
+
+// newMissingInterfaceError reports that v, the item at index, lacks missing.
+// The index is kept only when items are of interface type and so may differ
+// item by item; items of a concrete type all fail alike, which the error
+// reports as a failure of the type, with Index -1.
+func newMissingInterfaceError[T any](f Format, v any, index int, missing ...string) *MissingInterfaceError {
+	if reflect.TypeFor[T]().Kind() != reflect.Interface {
+		index = -1
+	}
+	e := &MissingInterfaceError{Format: f, Missing: missing, Type: fmt.Sprintf("%T", v), Index: index}
+	for _, sf := range Formats() {
+		if supports(sf, v) {
+			e.Supported = append(e.Supported, sf)
+		}
+	}
+	return e
+}
+
+func (e *MissingInterfaceError) Error() string {
+	msg := fmt.Sprintf("%s: format %q requires %s, not implemented by %s", ErrMissingInterface, e.Format, strings.Join(e.Missing, " and "), e.Type)
+	if e.Index >= 0 {
+		msg += fmt.Sprintf(" (item %d)", e.Index)
+	}
+	return msg
+}
+
+// Unwrap returns [ErrMissingInterface].
+func (e *MissingInterfaceError) Unwrap() error { return ErrMissingInterface }
+
 // Format represents an output format.
 type Format string
 
@@ -30,21 +74,24 @@ const (
 	TSV      Format = "tsv"
 	JSONL    Format = "jsonl"
 	HTML     Format = "html"
+	Vertical Format = "vertical"
+	Tree     Format = "tree"
 )
 
 const goTemplatePrefix = "go-template="
 
-var formats = []Format{JSON, YAML, CSV, Table, Markdown, List, ENV, Plain, TSV, JSONL, HTML}
+var formats = []Format{JSON, YAML, CSV, Table, Markdown, List, ENV, Plain, TSV, JSONL, HTML, Vertical, Tree}
 
 // String returns the format name.
 func (f Format) String() string { return string(f) }
 
-// Formats returns all supported static format names.
-// GoTemplate is not included because it is parameterized.
+// Formats returns all supported static format names, followed by formats
+// added with [RegisterFormat] in registration order.
+// Parameterized formats such as GoTemplate are not included.
 func Formats() []Format {
 	out := make([]Format, len(formats))
 	copy(out, formats)
-	return out
+	return append(out, registeredFormats()...)
 }
 
 // GoTemplate returns a Format that renders items using a Go text/template.
@@ -53,10 +100,17 @@ func GoTemplate(tmpl string) Format {
 	return Format(goTemplatePrefix + tmpl)
 }
 
-// ParseFormat parses a format string. Recognizes all static formats and
-// go-template=<tmpl> strings.
+// isParameterized reports whether f is a go-template, jsonpath, or
+// custom-columns format carrying its own argument.
+func isParameterized(f Format) bool {
+	return strings.HasPrefix(string(f), goTemplatePrefix) || strings.HasPrefix(string(f), jsonPathPrefix) || isCustomColumns(f)
+}
+
+// ParseFormat parses a format string. Recognizes all static formats,
+// registered formats, go-template=<tmpl>, jsonpath=<tmpl>,
+// custom-columns=<spec>, and custom-columns-file=<path> strings.
 func ParseFormat(s string) (Format, error) {
-	if strings.HasPrefix(s, goTemplatePrefix) {
+	if isParameterized(Format(s)) {
 		return Format(s), nil
 	}
 	for _, f := range formats {
@@ -64,36 +118,83 @@ func ParseFormat(s string) (Format, error) {
 			return f, nil
 		}
 	}
+	if _, ok := lookupFormat(Format(s)); ok {
+		return Format(s), nil
+	}
 	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, s)
 }
 
 // IsSupported reports whether type T implements the interfaces required by
-// format f. JSON, YAML, and GoTemplate always return true.
+// format f. JSON, YAML, GoTemplate, JSONPath, and CustomColumns always return
+// true.
+// Registered formats defer to the check given with [WithSupports].
 func IsSupported[T any](f Format) bool {
-	if strings.HasPrefix(string(f), goTemplatePrefix) {
+	var zero T
+	return supports(f, any(zero))
+}
+
+func supports(f Format, v any) bool {
+	if isParameterized(f) {
 		return true
 	}
-	var zero T
-	v := any(zero)
 	switch f {
 	case JSON, YAML, Plain, JSONL:
 		return true
-	case CSV, Table, TSV, HTML:
-		_, ok := v.(Rower)
-		return ok
-	case Markdown:
-		_, rower := v.(Rower)
-		_, headed := v.(Headed)
-		return rower && headed
+	case CSV, Table, TSV, HTML, Markdown, List, ENV, Vertical, Tree:
+		return (&options{}).missingFor(f, v) == nil
+	default:
+		rf, ok := lookupFormat(f)
+		if !ok {
+			return false
+		}
+		return rf.supports == nil || rf.supports(v)
+	}
+}
+
+// missingFor returns the names of the interfaces that built-in format f
+// requires and v lacks.
+func (o *options) missingFor(f Format, v any) []string {
+	var missing []string
+	switch f {
+	case CSV, Table, TSV, HTML, Markdown, Vertical:
+		if !o.isRower(v) {
+			missing = append(missing, "Rower")
+		}
+		if _, ok := o.headerOf(v); !ok && f == Markdown {
+			missing = append(missing, "Headed")
+		}
 	case List:
-		_, ok := v.(Lister)
-		return ok
+		if _, ok := v.(Lister); !ok {
+			missing = append(missing, "Lister")
+		}
 	case ENV:
-		_, ok := v.(Mappable)
-		return ok
-	default:
-		return false
+		if _, ok := v.(Mappable); !ok {
+			missing = append(missing, "Mappable")
+		}
+	case Tree:
+		if _, ok := v.(Parent); !ok {
+			missing = append(missing, "Parent")
+		}
+	}
+	return missing
+}
+
+// checkItems verifies that every item, not just the first, implements the
+// interfaces f requires, so interface-typed slices fail with an error naming
+// the offending index rather than a panic.
+func checkItems[T any](f Format, items []T, o *options) error {
+	for i, item := range items {
+		if _, ok := o.formattedAt(i); ok {
+			continue
+		}
+		if missing := o.missingFor(f, item); missing != nil {
+			return newMissingInterfaceError[T](f, item, i, missing...)
+		}
+		if err := o.checkRow(item, i); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // --- Core Format Interfaces ---
@@ -113,6 +214,14 @@ type Mappable interface {
 	Pairs() []KeyValue
 }
 
+// Parent provides the children of a hierarchical item, which may be of any
+// type and are Parents themselves to nest further. Required for Tree format.
+// With [WithTree], JSON, JSONL, and YAML also nest the children under a
+// "children" key. Children must not lead back to an ancestor.
+type Parent interface {
+	Children() []any
+}
+
 // KeyValue is a single key-value pair.
 type KeyValue struct {
 	Key   string
@@ -157,6 +266,15 @@ type Footered interface {
 	Footer() []string
 }
 
+// Aggregated computes footer cells over every row, for columns whose totals
+// the item cannot know on its own. Each column's [Aggregate] replaces its
+// [Footered] cell in Table, Markdown, HTML, and CSV output; numeric
+// aggregates understand durations and byte sizes such as "1.2 GiB".
+// Default: no aggregates.
+type Aggregated interface {
+	Aggregates() []Aggregate
+}
+
 // Numbered prepends a row number column.
 // Default: no row numbers.
 type Numbered interface {
@@ -210,23 +328,71 @@ type Styled interface {
 	Styles() []func(string) string
 }
 
-// Sorted is a metadata-only interface that declares a default sort column.
-// The package does NOT sort; callers (CLI frameworks) can read this to apply
-// sorting before rendering.
+// CellStyled styles individual data cells of an item's row in Table and
+// HTML format, such as a status cell colored by its value. col indexes into
+// the row before column selection. A style with a nil Apply leaves the cell
+// to its [Styled] column style.
+type CellStyled interface {
+	CellStyle(col int, value string) Style
+}
+
+// RowStyled styles an item's whole row in Table and HTML format. In Table
+// format its Apply wraps every cell after any cell or column style.
+type RowStyled interface {
+	RowStyle() Style
+}
+
+// Spanned lets cells of an item's row span several columns in Table and HTML
+// output. Spans returns, for each cell of Row(), the number of columns it
+// covers; the cells it covers are not drawn and are best left empty. Values
+// below 2 cover one column. Other formats keep every cell in its column.
+type Spanned interface {
+	Spans() []int
+}
+
+// HeaderGrouped adds a header row above [Headed] that groups adjacent
+// columns under a shared title, such as "Requests" over p50, p95, and p99,
+// in Table and HTML output. CSV, TSV, and Markdown, which have a single
+// header row, prefix each column's header with its group's title instead.
+type HeaderGrouped interface {
+	HeaderGroups() []HeaderGroup
+}
+
+// Sorted declares a default sort column, indexing into Row(). Items are only
+// sorted when the sort stage is enabled with [WithSort]; [WithSortBy]
+// overrides the declared column.
 type Sorted interface {
 	Sort() (column int, descending bool)
 }
 
 // Grouped returns a group key for the item. When consecutive items have
 // different group keys, a separator line is inserted between them in Table
-// format.
+// format, and HTML puts each group in its own <tbody>.
 type Grouped interface {
 	Group() string
 }
 
+// GroupHeaded names each group of [Grouped] items in a header row above its
+// rows: a full-width row styled like the title in Table, a header row in the
+// group's <tbody> in HTML, and a bold row in Markdown.
+// Default: no group headers.
+type GroupHeaded interface {
+	GroupHeader(group string) string
+}
+
+// Subtotaled adds a subtotal row after each group of [Grouped] items, with
+// each column's [Aggregate] computed over the group's rows, in Table, HTML,
+// and Markdown output.
+// Default: no subtotals.
+type Subtotaled interface {
+	Subtotals() []Aggregate
+}
+
 // Wrapped provides per-column maximum widths for text wrapping in Table
 // format. Cells exceeding the width wrap to multiple visual lines within
-// the same row. A zero value means no wrapping for that column.
+// the same row, breaking at spaces where they can and mid-word only for
+// words wider than the column. A zero value means no wrapping for that
+// column. Embedded newlines always start a new line, wrapped or not.
 type Wrapped interface {
 	WrapWidths() []int
 }
@@ -237,16 +403,33 @@ type Paged interface {
 	PageSize() int
 }
 
+// Fitted controls how Table columns shrink when the table is wider than its
+// maximum width, set with [WithMaxWidth] or detected from the terminal.
+// Default: every column has priority 0, truncates, and keeps 3 cells.
+type Fitted interface {
+	Fits() []Fit
+}
+
+// Themed sets the [Theme] for Table format.
+// Default: no theme.
+type Themed interface {
+	Theme() Theme
+}
+
 // Formatter is an escape hatch checked per-item. If Format returns non-nil
-// bytes, those bytes are written directly. If it returns (nil, nil), the
-// item falls through to default rendering.
+// bytes, they take the item's place in the output: JSON and YAML embed them
+// as the item's array element, the tabular formats read them as the item's
+// row (a CSV record for CSV, tab-separated cells otherwise), and the other
+// formats write them verbatim. If it returns (nil, nil), the item falls
+// through to default rendering. Either way, output keeps the input order.
 type Formatter interface {
 	Format(Format) ([]byte, error)
 }
 
 // --- Value Types ---
 
-// BorderStyle controls table border characters.
+// BorderStyle controls table border characters. Styles beyond the built-in
+// ones are added with [RegisterBorder].
 type BorderStyle int
 
 const (
@@ -268,98 +451,105 @@ const (
 
 // Write formats items and writes to w.
 func Write[T any](w io.Writer, f Format, items ...T) error {
-	if len(items) > 0 {
-		if fmtr, ok := any(items[0]).(Formatter); ok {
-			_ = fmtr // type check on first item
-			return writeFormatted(w, f, items)
-		}
-	}
-	switch f {
-	case JSON:
-		return writeJSON(w, items)
-	case YAML:
-		return writeYAML(w, items)
-	case CSV:
-		return writeCSV(w, items)
-	case Table:
-		return writeTable(w, items)
-	case Markdown:
-		return writeMarkdown(w, items)
-	case List:
-		return writeList(w, items)
-	case ENV:
-		return writeENV(w, items)
-	case Plain:
-		return writePlain(w, items)
-	case TSV:
-		return writeTSV(w, items)
-	case JSONL:
-		return writeJSONL(w, items)
-	case HTML:
-		return writeHTML(w, items)
-	default:
-		if tmpl, ok := strings.CutPrefix(string(f), goTemplatePrefix); ok {
-			return writeGoTemplate(w, tmpl, items)
-		}
-		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
-	}
+	return WriteWith(w, f, nil, items...)
+}
+
+// WriteWith formats items and writes to w, applying opts on top of the
+// metadata provided by the items' optional interfaces.
+func WriteWith[T any](w io.Writer, f Format, opts []Option, items ...T) error {
+	return writeItems(w, f, items, newOptions(opts))
 }
 
-func writeFormatted[T any](w io.Writer, f Format, items []T) error {
-	var fallback []T
-	for _, item := range items {
-		fmtr := any(item).(Formatter)
-		data, err := fmtr.Format(f)
+func writeItems[T any](w io.Writer, f Format, items []T, o *options) error {
+	if isCustomColumns(f) {
+		co, err := customColumnsTable(f, o)
 		if err != nil {
 			return err
 		}
-		if data != nil {
-			if _, werr := w.Write(data); werr != nil {
-				return werr
+		f, o = Table, co
+	}
+	if o.mixed != MixedFirst && o.rowSource == nil && isTabular(f) {
+		if groups, firsts := kindsOf(items); len(groups) > 1 {
+			if err := checkItems(f, items, o); err != nil {
+				return err
 			}
-			continue
+			if o.mixed == MixedSplit {
+				return writeSplit(w, f, groups, o)
+			}
+			co, err := unionColumns(f, items, firsts, o)
+			if err != nil {
+				return err
+			}
+			o = co
 		}
-		fallback = append(fallback, item)
 	}
-	if len(fallback) == 0 {
-		return nil
+	items, err := sortItems(items, o)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(items, isFormatter) {
+		return writeFormatted(w, f, items, o)
 	}
-	// Temporarily strip the Formatter interface by routing through standard dispatch.
+	return render(w, f, items, o)
+}
+
+// render dispatches items to the built-in or registered renderer for f.
+func render[T any](w io.Writer, f Format, items []T, o *options) error {
 	switch f {
 	case JSON:
-		return writeJSON(w, fallback)
+		return writeJSON(w, items, o)
 	case YAML:
-		return writeYAML(w, fallback)
+		return writeYAML(w, items, o)
 	case CSV:
-		return writeCSV(w, fallback)
+		return writeCSV(w, items, o)
 	case Table:
-		return writeTable(w, fallback)
+		return writeTable(w, items, o)
 	case Markdown:
-		return writeMarkdown(w, fallback)
+		return writeMarkdown(w, items, o)
 	case List:
-		return writeList(w, fallback)
+		return writeList(w, items, o)
 	case ENV:
-		return writeENV(w, fallback)
+		return writeENV(w, items, o)
 	case Plain:
-		return writePlain(w, fallback)
+		return writePlain(w, items, o)
 	case TSV:
-		return writeTSV(w, fallback)
+		return writeTSV(w, items, o)
 	case JSONL:
-		return writeJSONL(w, fallback)
+		return writeJSONL(w, items, o)
 	case HTML:
-		return writeHTML(w, fallback)
+		return writeHTML(w, items, o)
+	case Vertical:
+		return writeVertical(w, items, o)
+	case Tree:
+		return writeTree(w, items, o)
 	default:
 		if tmpl, ok := strings.CutPrefix(string(f), goTemplatePrefix); ok {
-			return writeGoTemplate(w, tmpl, fallback)
+			return writeGoTemplate(w, tmpl, items)
+		}
+		if tmpl, ok := strings.CutPrefix(string(f), jsonPathPrefix); ok {
+			return writeJSONPath(w, tmpl, items)
+		}
+		if rf, ok := lookupFormat(f); ok {
+			return writeRegistered(w, rf, items)
 		}
 		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
 	}
 }
 
+func isFormatter[T any](item T) bool {
+	_, ok := any(item).(Formatter)
+	return ok
+}
+
 // Marshal formats items and returns the bytes.
 func Marshal[T any](f Format, items ...T) ([]byte, error) {
+	return MarshalWith(f, nil, items...)
+}
+
+// MarshalWith formats items with opts applied and returns the bytes.
+func MarshalWith[T any](f Format, opts []Option, items ...T) ([]byte, error) {
 	var buf bytes.Buffer
-	if err := Write(&buf, f, items...); err != nil {
+	if err := WriteWith(&buf, f, opts, items...); err != nil {
 		return nil, err
 	}
 	return buf.Bytes(), nil
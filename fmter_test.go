@@ -2,9 +2,18 @@ package fmter_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/bjaus/fmter"
 	"github.com/mattn/go-runewidth"
@@ -217,12 +226,14 @@ func TestParseFormat(t *testing.T) {
 
 func TestFormats(t *testing.T) {
 	t.Parallel()
-	got := fmter.Formats()
-	assert.Equal(t, []fmter.Format{
+	builtins := []fmter.Format{
 		fmter.JSON, fmter.YAML, fmter.CSV, fmter.Table,
 		fmter.Markdown, fmter.List, fmter.ENV, fmter.Plain,
-		fmter.TSV, fmter.JSONL, fmter.HTML,
-	}, got)
+		fmter.TSV, fmter.JSONL, fmter.HTML, fmter.Vertical, fmter.Tree,
+	}
+	got := fmter.Formats()
+	// Registered formats (from parallel tests) follow the built-ins.
+	assert.Equal(t, builtins, got[:len(builtins)])
 	// Returned slice must be a copy.
 	got[0] = "modified"
 	assert.Equal(t, fmter.JSON, fmter.Formats()[0])
@@ -2778,3 +2789,3775 @@ func TestWriteTableWrappedStyledPlain(t *testing.T) {
 	out := buf.String()
 	assert.Contains(t, out, "[Hel")
 }
+
+// ============================================================
+// Format registry
+// ============================================================
+
+func renderUpper(w io.Writer, items []any) error {
+	for _, item := range items {
+		if _, err := fmt.Fprintln(w, strings.ToUpper(fmt.Sprint(item))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRegisterFormat(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-write")
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper))
+
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, f, "alice", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "ALICE\nBOB\n", buf.String())
+
+	data, err := fmter.Marshal(f, "carol")
+	require.NoError(t, err)
+	assert.Equal(t, "CAROL\n", string(data))
+
+	parsed, err := fmter.ParseFormat("upper-write")
+	require.NoError(t, err)
+	assert.Equal(t, f, parsed)
+	assert.Contains(t, fmter.Formats(), f)
+	assert.True(t, fmter.IsSupported[string](f))
+}
+
+func TestRegisterFormatSupports(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-supports")
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper, fmter.WithSupports(func(v any) bool {
+		_, ok := v.(fmt.Stringer)
+		return ok
+	})))
+	assert.True(t, fmter.IsSupported[stringerItem](f))
+	assert.False(t, fmter.IsSupported[string](f))
+}
+
+func TestRegisterFormatErrors(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		format fmter.Format
+		render fmter.Renderer
+		target error
+	}{
+		"empty name":      {format: "", render: renderUpper, target: fmter.ErrInvalidFormat},
+		"nil renderer":    {format: "upper-nil", render: nil, target: fmter.ErrInvalidFormat},
+		"reserved prefix": {format: fmter.GoTemplate("x"), render: renderUpper, target: fmter.ErrInvalidFormat},
+		"built-in":        {format: fmter.JSON, render: renderUpper, target: fmter.ErrFormatExists},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			err := fmter.RegisterFormat(tt.format, tt.render)
+			require.ErrorIs(t, err, tt.target)
+		})
+	}
+}
+
+func TestRegisterFormatDuplicate(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-dup")
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper))
+	err := fmter.RegisterFormat(f, renderUpper)
+	require.ErrorIs(t, err, fmter.ErrFormatExists)
+}
+
+func TestRegisterFormatWriteIterCollects(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-collect")
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper))
+	seq := func(yield func(string) bool) {
+		for _, s := range []string{"a", "b"} {
+			if !yield(s) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, f, seq)
+	require.NoError(t, err)
+	assert.Equal(t, "A\nB\n", buf.String())
+}
+
+func TestRegisterFormatWriteIterStreams(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-stream")
+	stream := func(w io.Writer, seq iter.Seq[any]) error {
+		for item := range seq {
+			if _, err := fmt.Fprintf(w, "<%v>", item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper, fmter.WithStreamRenderer(stream)))
+	ch := make(chan string, 2)
+	ch <- "a"
+	ch <- "b"
+	close(ch)
+	var buf bytes.Buffer
+	err := fmter.WriteChan(&buf, f, ch)
+	require.NoError(t, err)
+	assert.Equal(t, "<a><b>", buf.String())
+}
+
+func TestRegisterFormatFormatterFallthrough(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-formatter")
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper))
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, f, formattedItem{Name: "Alice"})
+	require.NoError(t, err)
+	assert.Equal(t, "{ALICE}\n", buf.String())
+}
+
+// ============================================================
+// Call-site options
+// ============================================================
+
+func TestWriteWithOverridesTable(t *testing.T) {
+	t.Parallel()
+	items := []richRow{
+		{Name: "Alice", Age: "30", Status: "active"},
+		{Name: "Bob", Age: "25", Status: "inactive"},
+	}
+	opts := []fmter.Option{
+		fmter.WithBorder(fmter.BorderASCII),
+		fmter.WithTitle("Staff"),
+		fmter.WithCaption(""),
+		fmter.WithAlignments(fmter.AlignRight),
+		fmter.WithNoHeaders(),
+		fmter.WithPageSize(1),
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, opts, items...)
+	require.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "Staff")
+	assert.Contains(t, out, "|   Bob |")
+	assert.NotContains(t, out, "People")
+	assert.NotContains(t, out, "2 results")
+	assert.NotContains(t, out, "Status")
+	assert.NotContains(t, out, "╭")
+}
+
+func TestWriteWithBorderOverridesNone(t *testing.T) {
+	t.Parallel()
+	items := []noBorderRow{{headedRow{basicRow{Name: "Alice", Age: "30"}}}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderDouble)}, items...)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "╔")
+}
+
+func TestWriteWithPageSize(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{
+		{basicRow{Name: "Alice", Age: "30"}},
+		{basicRow{Name: "Bob", Age: "25"}},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithPageSize(1)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, 2, strings.Count(buf.String(), "Name"))
+}
+
+func TestWriteWithCSV(t *testing.T) {
+	t.Parallel()
+	items := []tsvRow{
+		{headedRow{basicRow{Name: "Alice", Age: "30"}}},
+		{headedRow{basicRow{Name: "Bob", Age: "25"}}},
+	}
+	opts := []fmter.Option{fmter.WithDelimiter(';'), fmter.WithNoHeaders()}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CSV, opts, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice;30\nBob;25\n", buf.String())
+}
+
+func TestWriteWithTSVNoHeaders(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{{basicRow{Name: "Alice", Age: "30"}}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.TSV, []fmter.Option{fmter.WithNoHeaders()}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice\t30\n", buf.String())
+}
+
+func TestWriteWithJSONIndent(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		format fmter.Format
+		want   string
+	}{
+		"json":  {format: fmter.JSON, want: "{\n\t\"name\": \"Alice\"\n}\n"},
+		"jsonl": {format: fmter.JSONL, want: "{\n\t\"name\": \"Alice\"\n}\n"},
+		"yaml":  {format: fmter.YAML, want: "name: Alice\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.WriteWith(&buf, tt.format, []fmter.Option{fmter.WithIndent("\t")}, indentedVal{Name: "Alice"})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteWithENV(t *testing.T) {
+	t.Parallel()
+	items := []stubExportedEnv{{kvs: []fmter.KeyValue{{Key: "FOO", Value: "a b"}}}}
+	opts := []fmter.Option{fmter.WithExport(false), fmter.WithQuote(true)}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.ENV, opts, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "FOO=\"a b\"\n", buf.String())
+}
+
+func TestWriteWithList(t *testing.T) {
+	t.Parallel()
+	items := []stubSepList{{items: []string{"a", "b"}, sep: ", "}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.List, []fmter.Option{fmter.WithSeparator("|")}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "a|b\n", buf.String())
+}
+
+func TestWriteWithHTML(t *testing.T) {
+	t.Parallel()
+	items := []htmlRow{{headedRow{basicRow{Name: "Alice", Age: "30"}}}}
+	opts := []fmter.Option{fmter.WithTitle(""), fmter.WithNoHeaders(), fmter.WithAlignments(fmter.AlignCenter)}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.HTML, opts, items...)
+	require.NoError(t, err)
+	out := buf.String()
+	assert.NotContains(t, out, "<caption>")
+	assert.NotContains(t, out, "<thead>")
+	assert.Contains(t, out, `<td style="text-align: center">Alice</td>`)
+}
+
+func TestWriteWithMarkdownAlignments(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{{basicRow{Name: "Alice", Age: "30"}}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Markdown, []fmter.Option{fmter.WithAlignments(fmter.AlignLeft, fmter.AlignRight)}, items...)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "--:")
+}
+
+func TestMarshalWith(t *testing.T) {
+	t.Parallel()
+	data, err := fmter.MarshalWith(fmter.CSV, []fmter.Option{fmter.WithDelimiter('|')}, headedRow{basicRow{Name: "A", Age: "1"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Name|Age\nA|1\n", string(data))
+}
+
+func TestMarshalWithError(t *testing.T) {
+	t.Parallel()
+	_, err := fmter.MarshalWith(fmter.CSV, nil, "not a rower")
+	require.ErrorIs(t, err, fmter.ErrMissingInterface)
+}
+
+func TestWriteIterWithOptions(t *testing.T) {
+	t.Parallel()
+	items := []tsvRow{
+		{headedRow{basicRow{Name: "Alice", Age: "30"}}},
+		{headedRow{basicRow{Name: "Bob", Age: "25"}}},
+	}
+	seq := func(yield func(tsvRow) bool) {
+		for _, it := range items {
+			if !yield(it) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.CSV, seq, fmter.WithDelimiter(';'))
+	require.NoError(t, err)
+	assert.Equal(t, "Name;Age\nAlice;30\nBob;25\n", buf.String())
+}
+
+func TestWriteIterDelimitedSubsequentRows(t *testing.T) {
+	t.Parallel()
+	items := []tsvRow{
+		{headedRow{basicRow{Name: "Alice", Age: "30"}}},
+		{headedRow{basicRow{Name: "Bob", Age: "25"}}},
+	}
+	seq := func(yield func(tsvRow) bool) {
+		for _, it := range items {
+			if !yield(it) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.CSV, seq)
+	require.NoError(t, err)
+	assert.Equal(t, "Name\tAge\nAlice\t30\nBob\t25\n", buf.String())
+}
+
+func TestWriteChanWithOptions(t *testing.T) {
+	t.Parallel()
+	ch := make(chan indentedVal, 1)
+	ch <- indentedVal{Name: "Alice"}
+	close(ch)
+	var buf bytes.Buffer
+	err := fmter.WriteChan(&buf, fmter.JSON, ch, fmter.WithIndent("\t"))
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "\t\"name\"")
+}
+
+// ============================================================
+// Struct-tag derivation
+// ============================================================
+
+type taggedMeta struct {
+	Region string `fmter:"Region"`
+}
+
+type taggedService struct {
+	taggedMeta
+	Name     string   `fmter:"Name"`
+	Port     int      `fmter:"Port,align=right,width=6"`
+	Load     float64  `fmter:"Load,format=%.2f,align=center"`
+	Owner    *string  `fmter:""`
+	Replicas *int     `fmter:"Replicas,align=bogus,width=x"`
+	Labels   []string `fmter:"-"`
+	internal string   `fmter:"Internal"`
+	Untagged string
+}
+
+type taggedPtrMeta struct {
+	*taggedMeta
+	Name string `fmter:"Name"`
+}
+
+type taggedStringer struct {
+	ID *stringerItem `fmter:"ID"`
+}
+
+type taggedHeaded struct {
+	Name string `fmter:"Name"`
+}
+
+func (taggedHeaded) Header() []string { return []string{"NAME"} }
+
+func TestWriteTableTagged(t *testing.T) {
+	t.Parallel()
+	owner := "ops"
+	replicas := 3
+	items := []taggedService{
+		{taggedMeta: taggedMeta{Region: "us"}, Name: "api", Port: 8080, Load: 0.5, Owner: &owner, Replicas: &replicas, internal: "x"},
+		{taggedMeta: taggedMeta{Region: "eu"}, Name: "web", Port: 80, Load: 1.25},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderASCII)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"+--------+------+------+------+-------+----------+\n"+
+		"| Region | Name | Port | Load | Owner | Replicas |\n"+
+		"+--------+------+------+------+-------+----------+\n"+
+		"| us     | api  | 8080 | 0.50 | ops   | 3        |\n"+
+		"| eu     | web  |   80 | 1.25 |       |          |\n"+
+		"+--------+------+------+------+-------+----------+\n", buf.String())
+}
+
+func TestWriteTableTaggedMaxWidth(t *testing.T) {
+	t.Parallel()
+	items := []taggedService{{Name: "api", Port: 123456789}}
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.Table, items...)
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "123...")
+}
+
+func TestWriteTaggedFormats(t *testing.T) {
+	t.Parallel()
+	items := []taggedHeaded{{Name: "api"}, {Name: "web"}}
+	tests := map[string]struct {
+		format fmter.Format
+		want   string
+	}{
+		"csv":      {format: fmter.CSV, want: "NAME\napi\nweb\n"},
+		"tsv":      {format: fmter.TSV, want: "NAME\napi\nweb\n"},
+		"markdown": {format: fmter.Markdown, want: "| NAME |\n| ---- |\n| api  |\n| web  |\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.Write(&buf, tt.format, items...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteHTMLTagged(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.HTML, taggedService{Name: "api", Port: 80})
+	require.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "<th>Region</th>")
+	assert.Contains(t, out, `<td style="text-align: right">80</td>`)
+}
+
+func TestWriteTaggedPointers(t *testing.T) {
+	t.Parallel()
+	items := []*taggedService{{Name: "api", Port: 80}, nil}
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.CSV, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Region,Name,Port,Load,Owner,Replicas\n,api,80,0.00,,\n,,,,,\n", buf.String())
+}
+
+func TestWriteTaggedNilEmbedded(t *testing.T) {
+	t.Parallel()
+	items := []taggedPtrMeta{{Name: "api"}, {taggedMeta: &taggedMeta{Region: "us"}, Name: "web"}}
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.CSV, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Region,Name\n,api\nus,web\n", buf.String())
+}
+
+func TestWriteTaggedStringerPointer(t *testing.T) {
+	t.Parallel()
+	items := []taggedStringer{{ID: &stringerItem{name: "a"}}, {}}
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.CSV, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "ID\nSTRINGER:a\n\n", buf.String())
+}
+
+func TestWriteIterTagged(t *testing.T) {
+	t.Parallel()
+	seq := func(yield func(taggedHeaded) bool) {
+		for _, s := range []string{"api", "web"} {
+			if !yield(taggedHeaded{Name: s}) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.TSV, seq)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME\napi\nweb\n", buf.String())
+}
+
+func TestIsSupportedTagged(t *testing.T) {
+	t.Parallel()
+	assert.True(t, fmter.IsSupported[taggedService](fmter.Table))
+	assert.True(t, fmter.IsSupported[*taggedService](fmter.Markdown))
+	assert.False(t, fmter.IsSupported[tmplItem](fmter.CSV))
+	assert.False(t, fmter.IsSupported[any](fmter.CSV))
+}
+
+type taggedNumbered struct {
+	Name string `fmter:"Name,width=4"`
+}
+
+func (taggedNumbered) NumberHeader() string { return "#" }
+
+func TestWriteTableTaggedNumberedMaxWidth(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.Table, taggedNumbered{Name: "Alexander"})
+	require.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "│ 1 │ A... │")
+}
+
+// ============================================================
+// Column selection
+// ============================================================
+
+type columnRow struct {
+	Name   string
+	Age    string
+	Status string
+}
+
+func (r columnRow) Row() []string    { return []string{r.Name, r.Age, r.Status} }
+func (r columnRow) Header() []string { return []string{"Name", "Age", "Status"} }
+func (r columnRow) Alignments() []fmter.Alignment {
+	return []fmter.Alignment{fmter.AlignLeft, fmter.AlignRight, fmter.AlignCenter}
+}
+func (r columnRow) Footer() []string  { return []string{"Total", "55", ""} }
+func (r columnRow) MaxWidths() []int  { return []int{0, 0, 4} }
+func (r columnRow) WrapWidths() []int { return []int{0, 1, 0} }
+func (r columnRow) Styles() []func(string) string {
+	return []func(string) string{nil, func(s string) string { return "<" + s + ">" }}
+}
+
+func TestWriteWithColumnsTable(t *testing.T) {
+	t.Parallel()
+	items := []columnRow{{Name: "Alice", Age: "30", Status: "active"}}
+	opts := []fmter.Option{fmter.WithColumns("status", "AGE"), fmter.WithBorder(fmter.BorderASCII)}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, opts, items...)
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"+------+-----+\n"+
+		"| S... | <  A> |\n"+
+		"|      | <  g> |\n"+
+		"|      | <  e> |\n"+
+		"+------+-----+\n"+
+		"| a... | <  3> |\n"+
+		"|      | <  0> |\n"+
+		"+------+-----+\n"+
+		"|      | <  5> |\n"+
+		"|      | <  5> |\n"+
+		"+------+-----+\n", buf.String())
+}
+
+func TestWriteWithColumnsNumberedTable(t *testing.T) {
+	t.Parallel()
+	items := []richRow{{Name: "Alice", Age: "30", Status: "active"}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithColumns("Status")}, items...)
+	require.NoError(t, err)
+	out := buf.String()
+	assert.Contains(t, out, "│ # │ Status │")
+	assert.Contains(t, out, "│ 1 │ active │")
+	assert.NotContains(t, out, "Alice")
+}
+
+func TestWriteWithColumnsFormats(t *testing.T) {
+	t.Parallel()
+	items := []columnRow{{Name: "Alice", Age: "30", Status: "active"}}
+	tests := map[string]struct {
+		format fmter.Format
+		want   string
+	}{
+		"csv":      {format: fmter.CSV, want: "Status,Name\nactive,Alice\n"},
+		"tsv":      {format: fmter.TSV, want: "Status\tName\nactive\tAlice\n"},
+		"markdown": {format: fmter.Markdown, want: "| Status | Name      |\n| :----: | --------- |\n| active | Alice     |\n|        | **Total** |\n"},
+		"html": {format: fmter.HTML, want: "<table>\n  <thead>\n    <tr>\n" +
+			"      <th style=\"text-align: center\">Status</th>\n      <th>Name</th>\n" +
+			"    </tr>\n  </thead>\n  <tbody>\n    <tr>\n" +
+			"      <td style=\"text-align: center\">active</td>\n      <td>Alice</td>\n" +
+			"    </tr>\n  </tbody>\n  <tfoot>\n    <tr>\n" +
+			"      <td style=\"text-align: center\"></td>\n      <td>Total</td>\n" +
+			"    </tr>\n  </tfoot>\n</table>\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.WriteWith(&buf, tt.format, []fmter.Option{fmter.WithColumns("status", "name")}, items...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteWithColumnsNoHeaders(t *testing.T) {
+	t.Parallel()
+	items := []columnRow{{Name: "Alice", Age: "30", Status: "active"}}
+	opts := []fmter.Option{fmter.WithColumns("Age"), fmter.WithNoHeaders()}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CSV, opts, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "30\n", buf.String())
+}
+
+func TestWriteWithColumnsUnknown(t *testing.T) {
+	t.Parallel()
+	formats := []fmter.Format{fmter.Table, fmter.CSV, fmter.TSV, fmter.Markdown, fmter.HTML}
+	for _, f := range formats {
+		t.Run(string(f), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.WriteWith(&buf, f, []fmter.Option{fmter.WithColumns("bogus")}, headedRow{basicRow{Name: "A", Age: "1"}})
+			require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+			assert.Contains(t, err.Error(), `"bogus" (available: Name, Age)`)
+		})
+	}
+}
+
+func TestWriteWithColumnsNoHeader(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CSV, []fmter.Option{fmter.WithColumns("Name")}, basicRow{Name: "A", Age: "1"})
+	require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+	assert.Contains(t, err.Error(), "has no header")
+}
+
+func TestWriteIterWithColumns(t *testing.T) {
+	t.Parallel()
+	items := []columnRow{
+		{Name: "Alice", Age: "30", Status: "active"},
+		{Name: "Bob", Age: "25", Status: "idle"},
+	}
+	seq := func(yield func(columnRow) bool) {
+		for _, it := range items {
+			if !yield(it) {
+				return
+			}
+		}
+	}
+	tests := map[string]struct {
+		format fmter.Format
+		want   string
+	}{
+		"csv": {format: fmter.CSV, want: "Age,Name\n30,Alice\n25,Bob\n"},
+		"tsv": {format: fmter.TSV, want: "Age\tName\n30\tAlice\n25\tBob\n"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.WriteIter(&buf, tt.format, seq, fmter.WithColumns("age", "name"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteIterWithColumnsUnknown(t *testing.T) {
+	t.Parallel()
+	seq := func(yield func(headedRow) bool) {
+		yield(headedRow{basicRow{Name: "A", Age: "1"}})
+	}
+	for _, f := range []fmter.Format{fmter.CSV, fmter.TSV} {
+		var buf bytes.Buffer
+		err := fmter.WriteIter(&buf, f, seq, fmter.WithColumns("bogus"))
+		require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+		assert.Empty(t, buf.String())
+	}
+}
+
+// ============================================================
+// Sorting
+// ============================================================
+
+type sortableRow struct {
+	Name string
+	Port string
+	Size string
+}
+
+func (r sortableRow) Row() []string    { return []string{r.Name, r.Port, r.Size} }
+func (r sortableRow) Header() []string { return []string{"Name", "Port", "Size"} }
+
+type sortedSizeRow struct{ sortableRow }
+
+func (r sortedSizeRow) Sort() (column int, descending bool) { return 2, true }
+
+func TestWriteWithSortBy(t *testing.T) {
+	t.Parallel()
+	items := []sortableRow{
+		{Name: "web", Port: "80"},
+		{Name: "api", Port: "8080"},
+		{Name: "Cache", Port: "6379"},
+		{Name: "db", Port: "80"},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CSV, []fmter.Option{fmter.WithSortBy("port,-name")}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Port,Size\nweb,80,\ndb,80,\nCache,6379,\napi,8080,\n", buf.String())
+	// Caller's slice is not reordered.
+	assert.Equal(t, "web", items[0].Name)
+}
+
+func TestWriteWithSortByMultipleKeys(t *testing.T) {
+	t.Parallel()
+	items := []sortableRow{{Name: "web", Port: "80"}, {Name: "api", Port: "8080"}, {Name: "db", Port: "80"}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.TSV, []fmter.Option{fmter.WithSortBy("PORT", "+name")}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Name\tPort\tSize\ndb\t80\t\nweb\t80\t\napi\t8080\t\n", buf.String())
+}
+
+func TestWriteWithSortText(t *testing.T) {
+	t.Parallel()
+	items := []sortableRow{{Name: "web"}, {Name: "Cache"}, {Name: "api"}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Plain, []fmter.Option{fmter.WithSortBy("name")}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "{api  }\n{Cache  }\n{web  }\n", buf.String())
+}
+
+func TestWriteWithSortHonorsSorted(t *testing.T) {
+	t.Parallel()
+	items := []sortedSizeRow{
+		{sortableRow{Name: "web", Size: "1.5 GiB"}},
+		{sortableRow{Name: "api", Size: "900MiB"}},
+		{sortableRow{Name: "Cache", Size: "2GiB"}},
+		{sortableRow{Name: "db", Size: "12KB"}},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.TSV, []fmter.Option{fmter.WithSort(), fmter.WithNoHeaders(), fmter.WithColumns("name")}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Cache\nweb\napi\ndb\n", buf.String())
+
+	// Without WithSort, Sorted is not applied.
+	buf.Reset()
+	err = fmter.WriteWith(&buf, fmter.TSV, []fmter.Option{fmter.WithNoHeaders(), fmter.WithColumns("name")}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "web\napi\nCache\ndb\n", buf.String())
+}
+
+func TestWriteWithSortNoSorted(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Plain, []fmter.Option{fmter.WithSort()}, "b", "a")
+	require.NoError(t, err)
+	assert.Equal(t, "b\na\n", buf.String())
+}
+
+func TestWriteWithSortMixedCells(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{
+		{basicRow{Name: "n/a", Age: "x"}},
+		{basicRow{Name: "1h", Age: "x"}},
+		{basicRow{Name: "90s", Age: "x"}},
+		{basicRow{Name: "1,200", Age: "x"}},
+		{basicRow{Name: "NaN", Age: "x"}},
+		{basicRow{Name: "50%", Age: "x"}},
+		{basicRow{Name: "", Age: "x"}},
+		{basicRow{Name: "Nan", Age: "x"}},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.TSV, []fmter.Option{fmter.WithSortBy("name"), fmter.WithColumns("name"), fmter.WithNoHeaders()}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "50%\n1,200\n90s\n1h\n\nn/a\nNaN\nNan\n", buf.String())
+}
+
+func TestWriteWithSortByUnknown(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.JSON, []fmter.Option{fmter.WithSortBy("bogus")}, sortableRow{Name: "web"})
+	require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+	assert.Contains(t, err.Error(), "available: Name, Port, Size")
+
+	err = fmter.WriteWith(&buf, fmter.JSON, []fmter.Option{fmter.WithSortBy("name")}, "a", "b")
+	require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+	assert.Contains(t, err.Error(), "has no header")
+	assert.Empty(t, buf.String())
+}
+
+func TestWriteWithSortSortedOutOfRange(t *testing.T) {
+	t.Parallel()
+	items := []sortedRow{
+		{headedRow{basicRow{Name: "b", Age: "1"}}},
+		{headedRow{basicRow{Name: "a", Age: "2"}}},
+	}
+	var buf bytes.Buffer
+	// sortedRow sorts by column 1 (Age) descending.
+	err := fmter.WriteWith(&buf, fmter.CSV, []fmter.Option{fmter.WithSort()}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Age\na,2\nb,1\n", buf.String())
+}
+
+type sortedBeyondRow struct{ headedRow }
+
+func (sortedBeyondRow) Sort() (column int, descending bool) { return 5, false }
+
+func TestWriteWithSortColumnBeyondRow(t *testing.T) {
+	t.Parallel()
+	items := []sortedBeyondRow{{headedRow{basicRow{Name: "b"}}}, {headedRow{basicRow{Name: "a"}}}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.TSV, []fmter.Option{fmter.WithSort(), fmter.WithNoHeaders()}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "b\t\na\t\n", buf.String())
+}
+
+func TestWriteIterWithSortBy(t *testing.T) {
+	t.Parallel()
+	items := []sortableRow{
+		{Name: "web", Size: "1.5 GiB"},
+		{Name: "api", Size: "900MiB"},
+		{Name: "Cache", Size: "2GiB"},
+		{Name: "db", Size: "12KB"},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.CSV, slices.Values(items), fmter.WithSortBy("size"), fmter.WithColumns("name"))
+	require.NoError(t, err)
+	assert.Equal(t, "Name\ndb\napi\nweb\nCache\n", buf.String())
+}
+
+// --- Custom columns ---
+
+type ccPort struct {
+	Port     int    `json:"port"`
+	Protocol string `json:"protocol"`
+}
+
+type ccSpec struct {
+	Ports []ccPort `json:"ports"`
+}
+
+type ccService struct {
+	Name string `json:"name"`
+	Spec ccSpec `json:"spec"`
+	Tags []string
+}
+
+func TestWriteCustomColumns(t *testing.T) {
+	t.Parallel()
+	items := []ccService{
+		{Name: "web", Spec: ccSpec{Ports: []ccPort{{80, "TCP"}, {443, "TCP"}}}, Tags: []string{"a", "b"}},
+		{Name: "dns", Spec: ccSpec{Ports: []ccPort{{53, "UDP"}}}},
+	}
+	f := fmter.CustomColumns("NAME:.name,PORT:.spec.ports[0].port,LAST:{.spec.ports[-1].protocol},TAGS:.tags")
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, f, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME  PORT  LAST  TAGS\n----  ----  ----  ------\nweb   80    TCP   a,b\ndns   53    UDP   <none>\n", buf.String())
+}
+
+func TestWriteCustomColumnsMaps(t *testing.T) {
+	t.Parallel()
+	items := []map[string]any{
+		{"name": "a", "meta": map[string]any{"labels": map[string]any{"app": "x"}}},
+		{"name": "b"},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CustomColumns("NAME:.name,APP:.meta.labels.app,META:.meta"), []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME  APP     META\n----  ------  ----------------------\na     x       map[labels:map[app:x]]\nb     <none>  <none>\n", buf.String())
+
+	// Keys differing only in case resolve the same way on every run.
+	for range 20 {
+		buf.Reset()
+		item := map[string]any{"Name": "upper", "nAME": "mixed", "NAME": "caps"}
+		require.NoError(t, fmter.WriteWith(&buf, fmter.CustomColumns("N:.name"), []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithNoHeaders()}, item))
+		assert.Equal(t, "caps\n", buf.String())
+	}
+}
+
+func TestWriteCustomColumnsPathMismatch(t *testing.T) {
+	t.Parallel()
+	f := fmter.CustomColumns("A:.spec[0],B:.spec.ports.port,C:.spec.ports[-9],D:.name.x,E:.")
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, f, []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithNoHeaders()}, ccService{Name: "dns", Spec: ccSpec{Ports: []ccPort{{53, "UDP"}}}})
+	require.NoError(t, err)
+	assert.Equal(t, "<none>  <none>  <none>  <none>  map[Tags:<nil> name:dns spec:map[ports:[map[port:53 protocol:UDP]]]]\n", buf.String())
+}
+
+func TestWriteCustomColumnsUnencodable(t *testing.T) {
+	t.Parallel()
+	items := []map[string]any{{"x": 1}, {"x": func() {}}}
+	f := fmter.CustomColumns("X:.x")
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, f, items...)
+	var ute *json.UnsupportedTypeError
+	require.ErrorAs(t, err, &ute)
+	require.EqualError(t, err, "item 1: json: unsupported type: func()")
+	assert.Empty(t, buf.String())
+
+	err = fmter.WriteIter(&buf, f, slices.Values(items), fmter.WithStreamingTable(1, fmter.OverflowTruncate))
+	require.EqualError(t, err, "item 1: json: unsupported type: func()")
+}
+
+type ccBordered struct {
+	Name string `json:"name"`
+}
+
+func (ccBordered) Border() fmter.BorderStyle     { return fmter.BorderASCII }
+func (ccBordered) Alignments() []fmter.Alignment { return []fmter.Alignment{fmter.AlignRight} }
+func (ccBordered) MaxWidths() []int              { return []int{6} }
+
+func TestWriteCustomColumnsItemInterfaces(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.CustomColumns("NAME:.name"), ccBordered{Name: "x"}, ccBordered{Name: "longname"})
+	require.NoError(t, err)
+	assert.Equal(t, "+--------+\n|   NAME |\n+--------+\n|      x |\n| lon... |\n+--------+\n", buf.String())
+}
+
+type ccTagged struct {
+	Name string `fmter:"Name,width=2,align=right" json:"name"`
+	Port int    `fmter:"Port" json:"port"`
+}
+
+func TestWriteCustomColumnsIgnoresTags(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CustomColumns("PORT:.port,NAME:.name"), []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, ccTagged{Name: "long", Port: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "PORT  NAME\n----  ----\n1     long\n", buf.String())
+}
+
+func TestWriteCustomColumnsSortAndColumns(t *testing.T) {
+	t.Parallel()
+	items := []ccService{
+		{Name: "web", Spec: ccSpec{Ports: []ccPort{{80, "TCP"}, {443, "TCP"}}}, Tags: []string{"a", "b"}},
+		{Name: "dns", Spec: ccSpec{Ports: []ccPort{{53, "UDP"}}}},
+	}
+	opts := []fmter.Option{fmter.WithSortBy("port"), fmter.WithColumns("name"), fmter.WithBorder(fmter.BorderNone)}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CustomColumns("NAME:.name,PORT:.spec.ports[0].port"), opts, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME\n----\ndns\nweb\n", buf.String())
+}
+
+func TestWriteCustomColumnsFile(t *testing.T) {
+	t.Parallel()
+	items := []ccService{
+		{Name: "web", Spec: ccSpec{Ports: []ccPort{{80, "TCP"}, {443, "TCP"}}}, Tags: []string{"a", "b"}},
+		{Name: "dns", Spec: ccSpec{Ports: []ccPort{{53, "UDP"}}}},
+	}
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := dir + "/" + name
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	var buf bytes.Buffer
+	f := fmter.CustomColumnsFile(write("good.txt", "NAME   PORT\n.name  .spec.ports[0].port\n\n"))
+	err := fmter.WriteWith(&buf, f, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, "NAME  PORT\n----  ----\nweb   80\ndns   53\n", buf.String())
+
+	for name, content := range map[string]string{
+		"lines.txt":    "NAME\n",
+		"mismatch.txt": "NAME PORT\n.name\n",
+		"badpath.txt":  "NAME\nname\n",
+	} {
+		err := fmter.Write(io.Discard, fmter.CustomColumnsFile(write(name, content)), items...)
+		require.ErrorIs(t, err, fmter.ErrInvalidFormat, name)
+	}
+
+	err = fmter.Write(io.Discard, fmter.CustomColumnsFile(dir+"/missing.txt"), items...)
+	require.ErrorIs(t, err, fmter.ErrInvalidFormat)
+}
+
+func TestWriteCustomColumnsInvalid(t *testing.T) {
+	t.Parallel()
+	for _, spec := range []string{
+		"",
+		"NAME",
+		":.name",
+		"NAME:name",
+		"NAME:.a[0",
+		"NAME:.a[x]",
+		"NAME:.a[0]x",
+	} {
+		err := fmter.Write(io.Discard, fmter.CustomColumns(spec), ccService{Name: "web"})
+		require.ErrorIs(t, err, fmter.ErrInvalidFormat, spec)
+	}
+}
+
+func TestParseFormatCustomColumns(t *testing.T) {
+	t.Parallel()
+	f, err := fmter.ParseFormat("custom-columns=NAME:.name")
+	require.NoError(t, err)
+	assert.Equal(t, fmter.CustomColumns("NAME:.name"), f)
+
+	f, err = fmter.ParseFormat("custom-columns-file=cols.txt")
+	require.NoError(t, err)
+	assert.Equal(t, fmter.CustomColumnsFile("cols.txt"), f)
+
+	assert.True(t, fmter.IsSupported[string](fmter.CustomColumns("X:.x")))
+	assert.ErrorIs(t, fmter.RegisterFormat("custom-columns=x", func(io.Writer, []any) error { return nil }), fmter.ErrInvalidFormat)
+}
+
+func TestWriteIterCustomColumns(t *testing.T) {
+	t.Parallel()
+	items := []ccService{{Name: "web"}, {Name: "dns"}}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.CustomColumns("NAME:.name"), slices.Values(items), fmter.WithBorder(fmter.BorderNone), fmter.WithNoHeaders())
+	require.NoError(t, err)
+	assert.Equal(t, "web\ndns\n", buf.String())
+}
+
+// --- JSONPath ---
+
+type jpBook struct {
+	Title   string  `json:"title"`
+	Price   float64 `json:"price"`
+	ISBN    string  `json:"isbn,omitempty"`
+	InStock bool    `json:"inStock"`
+}
+
+type jpStore struct {
+	Name  string         `json:"name"`
+	Books []jpBook       `json:"books"`
+	Meta  map[string]any `json:"meta"`
+}
+
+func TestWriteJSONPath(t *testing.T) {
+	t.Parallel()
+	store := jpStore{
+		Name: "corner",
+		Books: []jpBook{
+			{Title: "A", Price: 8.95, InStock: true},
+			{Title: "B", Price: 12.99, ISBN: "0-553", InStock: false},
+			{Title: "C", Price: 22.99, ISBN: "0-395", InStock: true},
+		},
+		Meta: map[string]any{"city": "Oslo", "tags": []string{"x", "y"}, "owner": nil},
+	}
+	tests := map[string]struct {
+		tmpl string
+		want string
+	}{
+		"field":              {`{.name}`, "corner"},
+		"root":               {`{$.name}`, "corner"},
+		"current":            {`{@.name}`, "corner"},
+		"case fallback":      {`{.NAME}`, "corner"},
+		"text":               {`store: {.name}!`, "store: corner!"},
+		"literal":            {`{.name}{"\t"}{'x\n'}`, "corner\tx\\n"},
+		"index":              {`{.books[0].title}`, "A"},
+		"negative index":     {`{.books[-1].title}`, "C"},
+		"index list":         {`{.books[0,2].title}`, "A C"},
+		"out of range":       {`{.books[9].title}`, ""},
+		"wildcard":           {`{.books[*].title}`, "A B C"},
+		"dot wildcard":       {`{.meta.*}`, "Oslo  [\"x\",\"y\"]"},
+		"slice":              {`{.books[0:2].title}`, "A B"},
+		"slice open":         {`{.books[1:].title}`, "B C"},
+		"slice negative":     {`{.books[-2:].title}`, "B C"},
+		"slice step":         {`{.books[::2].title}`, "A C"},
+		"slice reverse":      {`{.books[::-1].title}`, "C B A"},
+		"slice reverse part": {`{.books[1:0:-1].title}`, "B"},
+		"slice clamp":        {`{.books[-9:9].title}`, "A B C"},
+		"quoted key":         {`{.meta['city']}`, "Oslo"},
+		"quoted keys":        {`{['name',"meta"].city}`, "Oslo"},
+		"union":              {`{.name,.meta.city}`, "corner Oslo"},
+		"recursive":          {`{..title}`, "A B C"},
+		"recursive bracket":  {`{..books[1].title}`, "B"},
+		"recursive index":    {`{.books..[0].title}`, "A"},
+		"recursive wildcard": {`{.meta..*}`, "Oslo  [\"x\",\"y\"] x y"},
+		"object":             {`{.books[0]}`, `{"inStock":true,"price":8.95,"title":"A"}`},
+		"array":              {`{.meta.tags}`, `["x","y"]`},
+		"bool and number":    {`{.books[0].inStock} {.books[0].price}`, "true 8.95"},
+		"null":               {`[{.meta.owner}]`, "[]"},
+		"missing":            {`[{.nope}]`, "[]"},
+		"key on array":       {`[{.books.title}]`, "[]"},
+		"index on map":       {`[{.meta[0]}]`, "[]"},
+		"filter lt":          {`{.books[?(@.price < 10)].title}`, "A"},
+		"filter le":          {`{.books[?(@.price <= 12.99)].title}`, "A B"},
+		"filter gt":          {`{.books[?(@.price > 10)].title}`, "B C"},
+		"filter ge":          {`{.books[?(@.price >= 22.99)].title}`, "C"},
+		"filter eq string":   {`{.books[?(@.title == 'B')].price}`, "12.99"},
+		"filter ne":          {`{.books[?(@.title != "B")].title}`, "A C"},
+		"filter bool":        {`{.books[?(@.inStock == true)].title}`, "A C"},
+		"filter bool false":  {`{.books[?(@.inStock == false)].title}`, "B"},
+		"filter mismatch":    {`{.books[?(@.title < 3)].title}`, ""},
+		"filter mismatch ne": {`{.books[?(@.title != 3)].title}`, "A B C"},
+		"filter null":        {`{.books[?(@.isbn == null)].title}`, ""},
+		"filter exists":      {`{.books[?(@.isbn)].title}`, "B C"},
+		"filter root":        {`{.books[?(@.title == $.books[2].title)].price}`, "22.99"},
+		"filter path rhs":    {`{.books[?(@.price > $.books[0].price)].title}`, "B C"},
+		"filter quoted op":   {`{.books[?('a<b' == @.title)].title}`, ""},
+		"escaped key":        {`{.meta["ci\"ty","city"]}`, "Oslo"},
+		"filter missing rhs": {`{.books[?(@.title == @.nope)].title}`, ""},
+		"range":              {`{range .books[*]}{.title}={.price};{end}`, "A=8.95;B=12.99;C=22.99;"},
+		"range array":        {`{range .books}{.title}{end}`, "ABC"},
+		"range root":         {`{range .books[*]}{$.name}{end}`, "cornercornercorner"},
+		"nested range":       {`{range .books[?(@.isbn)]}{.title}:{range .isbn}{@}{end} {end}`, "B:0-553 C:0-395 "},
+		"trailing dot":       {`{.meta.city.}`, "Oslo"},
+		"empty action":       {`{}x`, "x"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.Write(&buf, fmter.JSONPath(tc.tmpl), store)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want+"\n", buf.String())
+		})
+	}
+}
+
+func TestWriteJSONPathEachItem(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.JSONPath(`{.name}`), map[string]any{"name": "a"}, map[string]any{"name": "b"})
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWriteJSONPathFilterNull(t *testing.T) {
+	t.Parallel()
+	item := map[string]any{"items": []any{map[string]any{"v": nil, "n": "a"}, map[string]any{"v": 1, "n": "b"}}}
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.JSONPath(`{.items[?(@.v == null)].n},{.items[?(@.v != null)].n}`), item)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n", buf.String())
+}
+
+func TestWriteJSONPathInvalid(t *testing.T) {
+	t.Parallel()
+	for _, tmpl := range []string{
+		`{.name`,
+		`{end}`,
+		`{range .books[*]}{.title}`,
+		`{range name}{end}`,
+		`{range .books[*]}{name}{end}`,
+		`{name}`,
+		`{..}`,
+		`{...a}`,
+		`{.a[0}`,
+		`{.a]x[}`,
+		`{.a[0]x}`,
+		`{.a[x]}`,
+		`{.a[1:2:3:4]}`,
+		`{.a[::0]}`,
+		`{.a[x:]}`,
+		`{.a['b',c]}`,
+		`{.a[?@.b]}`,
+		`{.a[?(1)]}`,
+		`{.a[?(@.b == bogus)]}`,
+		`{.a[?(@.b == @name)]}`,
+		`{.a[?(@b)]}`,
+	} {
+		err := fmter.Write(io.Discard, fmter.JSONPath(tmpl), jpStore{Name: "corner"})
+		require.ErrorIs(t, err, fmter.ErrInvalidTemplate, tmpl)
+	}
+}
+
+func TestWriteJSONPathWriteError(t *testing.T) {
+	t.Parallel()
+	err := fmter.Write(&errWriter{}, fmter.JSONPath(`{.name}`), jpStore{Name: "corner"})
+	require.ErrorIs(t, err, errWriteFailed)
+}
+
+func TestWriteJSONPathUnencodable(t *testing.T) {
+	t.Parallel()
+	items := []map[string]any{{"x": 1}, {"x": func() {}}}
+	f := fmter.JSONPath(`{.x}`)
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, f, items...)
+	var ute *json.UnsupportedTypeError
+	require.ErrorAs(t, err, &ute)
+	require.EqualError(t, err, "item 1: json: unsupported type: func()")
+	assert.Equal(t, "1\n", buf.String())
+
+	err = fmter.WriteIter(io.Discard, f, slices.Values(items))
+	require.EqualError(t, err, "item 1: json: unsupported type: func()")
+}
+
+func TestWriteJSONPathItemsList(t *testing.T) {
+	t.Parallel()
+	items := []map[string]any{{"name": "a"}, {"name": "b"}}
+	for _, tmpl := range []string{`{.items[*].name}`, `{range .items[*]}{.name}{"\n"}{end}`, `{$.items[0].name}`} {
+		err := fmter.Write(io.Discard, fmter.JSONPath(tmpl), items...)
+		require.ErrorIs(t, err, fmter.ErrInvalidTemplate, tmpl)
+		require.ErrorContains(t, err, "item 0", tmpl)
+	}
+
+	err := fmter.WriteIter(io.Discard, fmter.JSONPath(`{.items[*].name}`), slices.Values(items))
+	require.ErrorIs(t, err, fmter.ErrInvalidTemplate)
+
+	// An item with an items field of its own reads it as usual, and a
+	// recursive or nested items key is never mistaken for a List.
+	var buf bytes.Buffer
+	list := map[string]any{"items": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}}
+	require.NoError(t, fmter.Write(&buf, fmter.JSONPath(`{.items[*].name}`), list))
+	require.NoError(t, fmter.Write(&buf, fmter.JSONPath(`{..items}{.spec.items}`), items[0]))
+	assert.Equal(t, "a b\n\n", buf.String())
+}
+
+func TestParseFormatJSONPath(t *testing.T) {
+	t.Parallel()
+	f, err := fmter.ParseFormat("jsonpath={.name}")
+	require.NoError(t, err)
+	assert.Equal(t, fmter.JSONPath("{.name}"), f)
+	assert.True(t, fmter.IsSupported[string](f))
+	assert.ErrorIs(t, fmter.RegisterFormat("jsonpath=x", func(io.Writer, []any) error { return nil }), fmter.ErrInvalidFormat)
+}
+
+func TestWriteIterJSONPath(t *testing.T) {
+	t.Parallel()
+	seq := func(yield func(map[string]any) bool) {
+		for _, name := range []string{"a", "b", "c"} {
+			if !yield(map[string]any{"name": name}) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteIter(&buf, fmter.JSONPath(`{.name}`), seq))
+	assert.Equal(t, "a\nb\nc\n", buf.String())
+
+	err := fmter.WriteIter(&errWriter{}, fmter.JSONPath(`{.name}`), seq)
+	require.ErrorIs(t, err, errWriteFailed)
+
+	err = fmter.WriteIter(io.Discard, fmter.JSONPath(`{.name`), seq)
+	require.ErrorIs(t, err, fmter.ErrInvalidTemplate)
+}
+
+// --- Streaming table ---
+
+type streamRichRow struct {
+	headedRow
+	group string
+}
+
+func (streamRichRow) Title() string    { return "People" }
+func (streamRichRow) Footer() []string { return []string{"Total", "4"} }
+func (streamRichRow) Caption() string  { return "4 results" }
+func (streamRichRow) PageSize() int    { return 2 }
+func (streamRichRow) MaxWidths() []int { return []int{0, 0} }
+func (r streamRichRow) Group() string  { return r.group }
+func (streamRichRow) Alignments() []fmter.Alignment {
+	return []fmter.Alignment{fmter.AlignLeft, fmter.AlignRight}
+}
+
+func TestWriteIterStreamingTableMatchesWrite(t *testing.T) {
+	t.Parallel()
+	items := []streamRichRow{
+		{headedRow{basicRow{"Alice", "30"}}, "A"},
+		{headedRow{basicRow{"Adam", "25"}}, "A"},
+		{headedRow{basicRow{"Bob", "35"}}, "B"},
+		{headedRow{basicRow{"Carol", "41"}}, "C"},
+	}
+	for _, border := range []fmter.BorderStyle{fmter.BorderRounded, fmter.BorderNone} {
+		var want, got bytes.Buffer
+		require.NoError(t, fmter.WriteWith(&want, fmter.Table, []fmter.Option{fmter.WithBorder(border)}, items...))
+		err := fmter.WriteIter(&got, fmter.Table, slices.Values(items), fmter.WithBorder(border), fmter.WithStreamingTable(10, fmter.OverflowTruncate))
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), got.String())
+	}
+}
+
+func TestWriteIterStreamingTableNumbered(t *testing.T) {
+	t.Parallel()
+	items := []richRow{{"Alice", "30", "active"}}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorder(fmter.BorderNone), fmter.WithStreamingTable(1, fmter.OverflowTruncate))
+	require.NoError(t, err)
+	// The number column reserves room for seven digits.
+	assert.Equal(t, ""+
+		"      #  Name   Age  Status\n"+
+		"-------  -----  ---  ------\n"+
+		"      1  Alice   30  active\n"+
+		"-------  -----  ---  ------\n"+
+		"         Total    2\n"+
+		"2 results\n", buf.String())
+}
+
+func TestWriteIterStreamingTableTruncate(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{
+		{basicRow{Name: "Al", Age: "30"}},
+		{basicRow{Name: "Bartholomew", Age: "25"}},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorder(fmter.BorderASCII), fmter.WithStreamingTable(1, fmter.OverflowTruncate))
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"+------+-----+\n"+
+		"| Name | Age |\n"+
+		"+------+-----+\n"+
+		"| Al   | 30  |\n"+
+		"| B... | 25  |\n"+
+		"+------+-----+\n", buf.String())
+}
+
+func TestWriteIterStreamingTableRepaginate(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{
+		{basicRow{Name: "Al", Age: "30"}},
+		{basicRow{Name: "Bo", Age: "5"}},
+		{basicRow{Name: "Bartholomew", Age: "25"}},
+		{basicRow{Name: "Cy", Age: "1"}},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorder(fmter.BorderASCII), fmter.WithTitle("People"), fmter.WithStreamingTable(1, fmter.OverflowRepaginate))
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"+------------+\n"+
+		"|   People   |\n"+
+		"+------+-----+\n"+
+		"| Name | Age |\n"+
+		"+------+-----+\n"+
+		"| Al   | 30  |\n"+
+		"| Bo   | 5   |\n"+
+		"+------+-----+\n"+
+		"+-------------+-----+\n"+
+		"| Name        | Age |\n"+
+		"+-------------+-----+\n"+
+		"| Bartholomew | 25  |\n"+
+		"| Cy          | 1   |\n"+
+		"+-------------+-----+\n", buf.String())
+
+	buf.Reset()
+	err = fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorder(fmter.BorderNone), fmter.WithStreamingTable(1, fmter.OverflowRepaginate))
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"Name  Age\n"+
+		"----  ---\n"+
+		"Al    30\n"+
+		"Bo    5\n"+
+		"\n"+
+		"Name         Age\n"+
+		"-----------  ---\n"+
+		"Bartholomew  25\n"+
+		"Cy           1\n", buf.String())
+}
+
+type streamCells []string
+
+func (c streamCells) Row() []string { return c }
+
+func TestWriteIterStreamingTableRepaginateExtraColumn(t *testing.T) {
+	t.Parallel()
+	items := []streamCells{{"a"}, {"b", "1"}}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorder(fmter.BorderNone), fmter.WithStreamingTable(1, fmter.OverflowRepaginate))
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb  1\n", buf.String())
+}
+
+type streamHintRow struct{ headedRow }
+
+func (streamHintRow) MaxWidths() []int  { return []int{6, 0} }
+func (streamHintRow) WrapWidths() []int { return []int{0, 2} }
+
+func TestWriteIterStreamingTableHints(t *testing.T) {
+	t.Parallel()
+	items := []streamHintRow{
+		{headedRow{basicRow{Name: "Al", Age: "1"}}},
+		{headedRow{basicRow{Name: "Bartholomew", Age: "1234"}}},
+	}
+	var buf bytes.Buffer
+	// Hinted columns keep their width, so nothing overflows.
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorder(fmter.BorderNone), fmter.WithStreamingTable(1, fmter.OverflowRepaginate))
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"Name    Ag\n"+
+		"        e\n"+
+		"------  --\n"+
+		"Al      1\n"+
+		"Bar...  12\n"+
+		"        34\n", buf.String())
+}
+
+func TestWriteIterStreamingTableBoundedMemory(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	seq := func(yield func(headedRow) bool) {
+		for i := range 100 {
+			if i == 3 {
+				// The sample of two rows has been written already.
+				assert.Contains(t, buf.String(), "r1")
+			}
+			if !yield(headedRow{basicRow{Name: fmt.Sprintf("r%d", i)}}) {
+				return
+			}
+		}
+	}
+	err := fmter.WriteIter(&buf, fmter.Table, seq, fmter.WithStreamingTable(2, fmter.OverflowTruncate))
+	require.NoError(t, err)
+	assert.Contains(t, buf.String(), "r99")
+}
+
+func TestWriteIterStreamingTableEdges(t *testing.T) {
+	t.Parallel()
+	stream := fmter.WithStreamingTable(0, fmter.OverflowRepaginate)
+
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteIter(&buf, fmter.Table, slices.Values([]headedRow(nil)), stream))
+	assert.Empty(t, buf.String())
+
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values([]string{"x"}), stream)
+	require.ErrorIs(t, err, fmter.ErrMissingInterface)
+
+	// Formatter items fall back to collecting every item.
+	items := []formattedRower{{basicRow{Name: "Alice", Age: "30"}}, {basicRow{Name: "Bob", Age: "25"}}}
+	require.NoError(t, fmter.WriteIter(&buf, fmter.Table, slices.Values(items), stream, fmter.WithBorder(fmter.BorderNone)))
+	assert.Equal(t, "Name   Age\n-----  ---\nAlice  30\nBob    25\n", buf.String())
+}
+
+func TestWriteIterStreamingTableWriteErrors(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{
+		{basicRow{Name: "Al", Age: "30"}},
+		{basicRow{Name: "Bo", Age: "31"}},
+		{basicRow{Name: "Bartholomew", Age: "25"}},
+	}
+	for _, border := range []fmter.BorderStyle{fmter.BorderASCII, fmter.BorderNone} {
+		for _, sample := range []int{1, 5} {
+			for n := range 12 {
+				w := &failAfterN{n: n}
+				err := fmter.WriteIter(w, fmter.Table, slices.Values(items), fmter.WithBorder(border), fmter.WithTitle("T"), fmter.WithCaption("c"), fmter.WithStreamingTable(sample, fmter.OverflowRepaginate))
+				if err != nil {
+					require.ErrorIs(t, err, errWriteFailed)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteIterStreamingCustomColumns(t *testing.T) {
+	t.Parallel()
+	items := []ccService{{Name: "web"}, {Name: "dns"}}
+	stream := fmter.WithStreamingTable(1, fmter.OverflowTruncate)
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.CustomColumns("NAME:.name"), slices.Values(items), stream, fmter.WithBorder(fmter.BorderNone))
+	require.NoError(t, err)
+	assert.Equal(t, "NAME\n----\nweb\ndns\n", buf.String())
+
+	err = fmter.WriteIter(&buf, fmter.CustomColumns(""), slices.Values(items), stream)
+	require.ErrorIs(t, err, fmter.ErrInvalidFormat)
+}
+
+// --- WriteIter2 ---
+
+var errPage = errors.New("page 3 failed")
+
+// failingSeq yields items and then fails with errPage.
+func failingSeq[T any](items ...T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for _, it := range items {
+			if !yield(it, nil) {
+				return
+			}
+		}
+		var zero T
+		yield(zero, errPage)
+	}
+}
+
+func TestWriteIter2ProducerError(t *testing.T) {
+	t.Parallel()
+	items := []headedRow{{basicRow{Name: "Alice", Age: "30"}}, {basicRow{Name: "Bob", Age: "25"}}}
+	tests := map[string]struct {
+		format fmter.Format
+		opts   []fmter.Option
+		want   string
+	}{
+		"json": {format: fmter.JSON, want: `[{"Name":"Alice","Age":"30"}` + "\n" + `,{"Name":"Bob","Age":"25"}` + "\n]\n"},
+		"csv":  {format: fmter.CSV, want: "Name,Age\nAlice,30\nBob,25\n"},
+		"table": {format: fmter.Table, opts: []fmter.Option{fmter.WithBorder(fmter.BorderASCII)}, want: "" +
+			"+-------+-----+\n" +
+			"| Name  | Age |\n" +
+			"+-------+-----+\n" +
+			"| Alice | 30  |\n" +
+			"| Bob   | 25  |\n" +
+			"+-------+-----+\n"},
+		"streaming table": {format: fmter.Table, opts: []fmter.Option{fmter.WithBorder(fmter.BorderASCII), fmter.WithStreamingTable(1, fmter.OverflowTruncate)}, want: "" +
+			"+-------+-----+\n" +
+			"| Name  | Age |\n" +
+			"+-------+-----+\n" +
+			"| Alice | 30  |\n" +
+			"| Bob   | 25  |\n" +
+			"+-------+-----+\n"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.WriteIter2(&buf, tc.format, failingSeq(items...), tc.opts...)
+			require.ErrorIs(t, err, errPage)
+			assert.EqualError(t, err, "item 2: page 3 failed")
+			assert.Equal(t, tc.want, buf.String())
+		})
+	}
+}
+
+func TestWriteIter2HTMLClosed(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteIter2(&buf, fmter.HTML, failingSeq(htmlRow{headedRow{basicRow{Name: "Alice", Age: "30"}}}))
+	require.ErrorIs(t, err, errPage)
+	assert.Contains(t, buf.String(), "Alice")
+	assert.True(t, strings.HasSuffix(buf.String(), "</table>\n"))
+}
+
+func TestWriteIter2FirstItemError(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteIter2(&buf, fmter.JSON, failingSeq[headedRow]())
+	assert.EqualError(t, err, "item 0: page 3 failed")
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestWriteIter2NoError(t *testing.T) {
+	t.Parallel()
+	seq := func(yield func(string, error) bool) {
+		for _, s := range []string{"a", "b"} {
+			if !yield(s, nil) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteIter2(&buf, fmter.Plain, seq))
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWriteIter2WriteErrorWins(t *testing.T) {
+	t.Parallel()
+	consumed := 0
+	seq := func(yield func(string, error) bool) {
+		for _, s := range []string{"a", "b", "c"} {
+			consumed++
+			if !yield(s, nil) {
+				return
+			}
+		}
+		yield("", errPage)
+	}
+	err := fmter.WriteIter2(&errWriter{}, fmter.Plain, seq)
+	require.ErrorIs(t, err, errWriteFailed)
+	assert.Equal(t, 1, consumed)
+}
+
+// --- Context cancellation ---
+
+func TestWriteIterContextCanceled(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seq := func(yield func(headedRow) bool) {
+		for _, name := range []string{"Alice", "Bob", "Carol"} {
+			if name == "Carol" {
+				cancel()
+			}
+			if !yield(headedRow{basicRow{Name: name, Age: "1"}}) {
+				return
+			}
+		}
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIterContext(ctx, &buf, fmter.Table, seq, fmter.WithBorder(fmter.BorderASCII))
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, ""+
+		"+-------+-----+\n"+
+		"| Name  | Age |\n"+
+		"+-------+-----+\n"+
+		"| Alice | 1   |\n"+
+		"| Bob   | 1   |\n"+
+		"+-------+-----+\n", buf.String())
+}
+
+func TestWriteIterContextNotCanceled(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteIterContext(context.Background(), &buf, fmter.Plain, slices.Values([]string{"a", "b"})))
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWriteIterContextWriteError(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := fmter.WriteIterContext(ctx, &errWriter{}, fmter.JSON, slices.Values([]string{"a"}))
+	require.ErrorIs(t, err, errWriteFailed)
+}
+
+func TestWriteChanContextDeadline(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// The producer never closes the channel.
+	ch := make(chan string, 2)
+	ch <- "a"
+	ch <- "b"
+	var buf bytes.Buffer
+	err := fmter.WriteChanContext(ctx, &buf, fmter.JSON, ch)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, "[\"a\"\n,\"b\"\n]\n", buf.String())
+}
+
+func TestWriteChanContextCanceledBeforeItem(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ch := make(chan string, 1)
+	ch <- "a"
+	var buf bytes.Buffer
+	err := fmter.WriteChanContext(ctx, &buf, fmter.JSON, ch)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "[]\n", buf.String())
+}
+
+func TestWriteChanContextClosed(t *testing.T) {
+	t.Parallel()
+	ch := make(chan string, 2)
+	ch <- "a"
+	ch <- "b"
+	close(ch)
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteChanContext(context.Background(), &buf, fmter.Plain, ch))
+	assert.Equal(t, "a\nb\n", buf.String())
+
+	ch = make(chan string, 1)
+	ch <- "a"
+	err := fmter.WriteChanContext(context.Background(), &errWriter{}, fmter.Plain, ch)
+	require.ErrorIs(t, err, errWriteFailed)
+}
+
+func TestWriteIter2Context(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	seq := func(yield func(string, error) bool) {
+		if !yield("a", nil) {
+			return
+		}
+		cancel()
+		yield("b", nil)
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter2Context(ctx, &buf, fmter.JSON, seq)
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, "[\"a\"\n]\n", buf.String())
+
+	// Producer errors are still reported with their index.
+	err = fmter.WriteIter2Context(context.Background(), &buf, fmter.JSON, failingSeq("a"))
+	assert.EqualError(t, err, "item 1: page 3 failed")
+
+	require.NoError(t, fmter.WriteIter2Context(context.Background(), io.Discard, fmter.JSON, func(func(string, error) bool) {}))
+}
+
+// --- MissingInterfaceError ---
+
+func TestMissingInterfaceError(t *testing.T) {
+	t.Parallel()
+	err := fmter.Write(io.Discard, fmter.CSV, tmplItem{Name: "x"})
+	require.ErrorIs(t, err, fmter.ErrMissingInterface)
+
+	var mie *fmter.MissingInterfaceError
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, fmter.CSV, mie.Format)
+	assert.Equal(t, []string{"Rower"}, mie.Missing)
+	assert.Equal(t, "fmter_test.tmplItem", mie.Type)
+	assert.Equal(t, []fmter.Format{fmter.JSON, fmter.YAML, fmter.Plain, fmter.JSONL}, mie.Supported[:4])
+	assert.EqualError(t, err, `missing required interface: format "csv" requires Rower, not implemented by fmter_test.tmplItem`)
+}
+
+func TestMissingInterfaceErrorMarkdown(t *testing.T) {
+	t.Parallel()
+	var mie *fmter.MissingInterfaceError
+
+	err := fmter.Write(io.Discard, fmter.Markdown, "x")
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, []string{"Rower", "Headed"}, mie.Missing)
+	assert.EqualError(t, err, `missing required interface: format "markdown" requires Rower and Headed, not implemented by string`)
+
+	err = fmter.Write(io.Discard, fmter.Markdown, basicRow{Name: "x"})
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, []string{"Headed"}, mie.Missing)
+	assert.Contains(t, mie.Supported, fmter.Table)
+	assert.NotContains(t, mie.Supported, fmter.Markdown)
+}
+
+func TestMissingInterfaceErrorFormats(t *testing.T) {
+	t.Parallel()
+	tests := map[fmter.Format]string{
+		fmter.CSV:      "Rower",
+		fmter.TSV:      "Rower",
+		fmter.Table:    "Rower",
+		fmter.HTML:     "Rower",
+		fmter.Vertical: "Rower",
+		fmter.List:     "Lister",
+		fmter.ENV:      "Mappable",
+		fmter.Tree:     "Parent",
+	}
+	for f, missing := range tests {
+		var mie *fmter.MissingInterfaceError
+		require.ErrorAs(t, fmter.Write(io.Discard, f, 42), &mie, f)
+		assert.Equal(t, []string{missing}, mie.Missing, f)
+		assert.Equal(t, "int", mie.Type, f)
+
+		if f == fmter.CSV || f == fmter.TSV || f == fmter.Vertical {
+			require.ErrorAs(t, fmter.WriteIter(io.Discard, f, slices.Values([]int{42})), &mie, f)
+			assert.Equal(t, f, mie.Format)
+		}
+	}
+}
+
+// --- Mixed item types ---
+
+type resource interface{ Kind() string }
+
+type podRes struct{ name, status string }
+
+func (podRes) Kind() string     { return "Pod" }
+func (p podRes) Row() []string  { return []string{p.name, p.status} }
+func (podRes) Header() []string { return []string{"Name", "Status"} }
+func (podRes) Group() string    { return "pods" }
+func (p podRes) List() []string { return []string{p.name} }
+func (p podRes) Pairs() []fmter.KeyValue {
+	return []fmter.KeyValue{{Key: "NAME", Value: p.name}}
+}
+
+type svcRes struct {
+	name string
+	port int
+}
+
+func (svcRes) Kind() string     { return "Service" }
+func (s svcRes) Row() []string  { return []string{s.name, strconv.Itoa(s.port)} }
+func (svcRes) Header() []string { return []string{"Name", "Port"} }
+func (svcRes) Alignments() []fmter.Alignment {
+	return []fmter.Alignment{fmter.AlignLeft, fmter.AlignRight}
+}
+
+type bareRes struct{}
+
+func (bareRes) Kind() string { return "Bare" }
+
+type unheadedRes struct{}
+
+func (unheadedRes) Kind() string  { return "Unheaded" }
+func (unheadedRes) Row() []string { return []string{"x"} }
+
+func TestWriteMixedMissingInterfaceIndex(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"a", "Running"}, podRes{"b", "Running"}, bareRes{}}
+	for _, f := range []fmter.Format{fmter.CSV, fmter.TSV, fmter.Table, fmter.Markdown, fmter.HTML, fmter.List, fmter.ENV} {
+		var mie *fmter.MissingInterfaceError
+		err := fmter.Write(io.Discard, f, items...)
+		require.ErrorAs(t, err, &mie, f)
+		assert.Equal(t, 2, mie.Index, f)
+		assert.Equal(t, "fmter_test.bareRes", mie.Type, f)
+		assert.Contains(t, err.Error(), "not implemented by fmter_test.bareRes (item 2)", f)
+	}
+}
+
+func TestMissingInterfaceErrorIndex(t *testing.T) {
+	t.Parallel()
+	// The first item of a mixed slice is reported by index like any other.
+	var mie *fmter.MissingInterfaceError
+	err := fmter.Write(io.Discard, fmter.CSV, []resource{bareRes{}, podRes{"a", "Running"}}...)
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, 0, mie.Index)
+	assert.ErrorContains(t, err, "not implemented by fmter_test.bareRes (item 0)")
+
+	// Items of a concrete type fail as a whole type.
+	err = fmter.Write(io.Discard, fmter.CSV, "a", "b")
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, -1, mie.Index)
+	assert.EqualError(t, err, `missing required interface: format "csv" requires Rower, not implemented by string`)
+}
+
+func TestWriteIterMixedMissingInterfaceIndex(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"a", "Running"}, bareRes{}}
+	for _, opts := range [][]fmter.Option{nil, {fmter.WithStreamingTable(1, fmter.OverflowTruncate)}} {
+		for _, f := range []fmter.Format{fmter.CSV, fmter.TSV, fmter.Table} {
+			var mie *fmter.MissingInterfaceError
+			err := fmter.WriteIter(io.Discard, f, slices.Values(items), opts...)
+			require.ErrorAs(t, err, &mie, f)
+			assert.Equal(t, 1, mie.Index, f)
+		}
+	}
+	var mie *fmter.MissingInterfaceError
+	err := fmter.WriteIter(io.Discard, fmter.Table, slices.Values(items), fmter.WithStreamingTable(5, fmter.OverflowTruncate))
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, 1, mie.Index)
+}
+
+func TestWriteMixedFirstGroupedNoPanic(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"web-1", "Running"}, svcRes{"web", 80}, podRes{"web-2", "Pending"}, svcRes{"dns", 53}}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"Name   Status\n"+
+		"-----  -------\n"+
+		"web-1  Running\n"+
+		"-----  -------\n"+
+		"web    80\n"+
+		"-----  -------\n"+
+		"web-2  Pending\n"+
+		"-----  -------\n"+
+		"dns    53\n", buf.String())
+}
+
+type formattedRes struct{ podRes }
+
+func (f formattedRes) Format(fmter.Format) ([]byte, error) {
+	return []byte("custom " + f.name + "\n"), nil
+}
+
+func TestWriteMixedFormatterLaterItem(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.Write(&buf, fmter.CSV, resource(podRes{"a", "Running"}), resource(formattedRes{podRes{"b", "Running"}}))
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Status\na,Running\ncustom b\n", buf.String())
+}
+
+func TestWriteMixedUnion(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"web-1", "Running"}, svcRes{"web", 80}, podRes{"web-2", "Pending"}, svcRes{"dns", 53}}
+	opts := []fmter.Option{fmter.WithMixed(fmter.MixedUnion)}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CSV, opts, items...))
+	assert.Equal(t, "Name,Status,Port\nweb-1,Running,\nweb,,80\nweb-2,Pending,\ndns,,53\n", buf.String())
+
+	buf.Reset()
+	opts = append(opts, fmter.WithBorder(fmter.BorderASCII), fmter.WithSortBy("port"))
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, items...))
+	assert.Equal(t, ""+
+		"+-------+---------+------+\n"+
+		"| Name  | Status  | Port |\n"+
+		"+-------+---------+------+\n"+
+		"| dns   |         |   53 |\n"+
+		"| web   |         |   80 |\n"+
+		"| web-1 | Running |      |\n"+
+		"| web-2 | Pending |      |\n"+
+		"+-------+---------+------+\n", buf.String())
+}
+
+type countedPod struct{ podRes }
+
+func (countedPod) Aggregates() []fmter.Aggregate {
+	return []fmter.Aggregate{fmter.AggregateCount}
+}
+
+type summedSvc struct{ svcRes }
+
+func (summedSvc) Group() string { return "svcs" }
+func (summedSvc) Aggregates() []fmter.Aggregate {
+	return []fmter.Aggregate{fmter.AggregateNone, fmter.AggregateSum}
+}
+func (summedSvc) Subtotals() []fmter.Aggregate {
+	return []fmter.Aggregate{fmter.AggregateNone, fmter.AggregateSum}
+}
+
+type groupedSvc struct{ svcRes }
+
+func (groupedSvc) HeaderGroups() []fmter.HeaderGroup {
+	return []fmter.HeaderGroup{{Title: "Svc", Span: 2}}
+}
+
+func TestWriteMixedUnionAggregates(t *testing.T) {
+	t.Parallel()
+	// Each type's aggregates follow its columns into the union.
+	items := []resource{countedPod{podRes{"web-1", "Running"}}, summedSvc{svcRes{"web", 80}}, summedSvc{svcRes{"dns", 53}}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CSV, []fmter.Option{fmter.WithMixed(fmter.MixedUnion)}, items...))
+	assert.Equal(t, "Name,Status,Port\nweb-1,Running,\nweb,,80\ndns,,53\n3,,133\n", buf.String())
+
+	// So do subtotals, here only the services'.
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Markdown, []fmter.Option{fmter.WithMixed(fmter.MixedUnion)}, items...))
+	assert.Equal(t, ""+
+		"| Name  | Status  |    Port |\n"+
+		"| ----- | ------- | ------: |\n"+
+		"| web-1 | Running |         |\n"+
+		"|       |         |         |\n"+
+		"| web   |         |      80 |\n"+
+		"| dns   |         |      53 |\n"+
+		"|       |         | **133** |\n"+
+		"| **3** |         | **133** |\n", buf.String())
+}
+
+func TestWriteMixedUnionHeaderGroups(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"web-1", "Running"}, groupedSvc{svcRes{"web", 80}}}
+	err := fmter.WriteWith(io.Discard, fmter.Table, []fmter.Option{fmter.WithMixed(fmter.MixedUnion)}, items...)
+	require.ErrorIs(t, err, fmter.ErrInvalidFormat)
+
+	// Groups set for the union's own columns apply.
+	var buf bytes.Buffer
+	opts := []fmter.Option{fmter.WithMixed(fmter.MixedUnion), fmter.WithHeaderGroups(fmter.HeaderGroup{Title: "Pod", Span: 2})}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CSV, opts, items...))
+	assert.Equal(t, "Pod Name,Pod Status,Port\nweb-1,Running,\nweb,,80\n", buf.String())
+}
+
+func TestWriteMixedUnionMissingHeader(t *testing.T) {
+	t.Parallel()
+	var mie *fmter.MissingInterfaceError
+	err := fmter.WriteWith(io.Discard, fmter.CSV, []fmter.Option{fmter.WithMixed(fmter.MixedUnion)}, resource(podRes{"a", ""}), resource(unheadedRes{}))
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, []string{"Headed"}, mie.Missing)
+	assert.Equal(t, 1, mie.Index)
+}
+
+func TestWriteMixedSplit(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"web-1", "Running"}, svcRes{"web", 80}, podRes{"web-2", "Pending"}, svcRes{"dns", 53}}
+	opts := []fmter.Option{fmter.WithMixed(fmter.MixedSplit)}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CSV, opts, items...))
+	assert.Equal(t, "Name,Status\nweb-1,Running\nweb-2,Pending\n\nName,Port\nweb,80\ndns,53\n", buf.String())
+
+	var mie *fmter.MissingInterfaceError
+	require.ErrorAs(t, fmter.WriteWith(io.Discard, fmter.CSV, opts, append(items, bareRes{})...), &mie)
+	assert.Equal(t, 4, mie.Index)
+
+	for n := range 5 {
+		err := fmter.WriteWith(&failAfterN{n: n}, fmter.TSV, opts, items...)
+		require.ErrorIs(t, err, errWriteFailed, n)
+	}
+}
+
+func TestWriteMixedNonTabular(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.List, []fmter.Option{fmter.WithMixed(fmter.MixedSplit)}, resource(podRes{"a", ""}), resource(podRes{"b", ""}))
+	require.NoError(t, err)
+	assert.Equal(t, "a\nb\n", buf.String())
+}
+
+func TestWriteIterStreamingTableUnknownColumn(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"web-1", "Running"}, svcRes{"web", 80}}
+	err := fmter.WriteIter(io.Discard, fmter.Table, slices.Values(items), fmter.WithStreamingTable(1, fmter.OverflowTruncate), fmter.WithColumns("nope"))
+	require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+}
+
+func TestWriteIterMixedUnion(t *testing.T) {
+	t.Parallel()
+	items := []resource{podRes{"web-1", "Running"}, svcRes{"web", 80}}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.TSV, slices.Values(items), fmter.WithMixed(fmter.MixedUnion), fmter.WithNoHeaders())
+	require.NoError(t, err)
+	assert.Equal(t, "web-1\tRunning\t\nweb\t\t80\n", buf.String())
+}
+
+func TestWriteMixedSingleKind(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.CSV, []fmter.Option{fmter.WithMixed(fmter.MixedSplit)}, resource(podRes{"a", "Running"}))
+	require.NoError(t, err)
+	assert.Equal(t, "Name,Status\na,Running\n", buf.String())
+}
+
+// --- Formatter output order ---
+
+// perFormat is a Formatter returning the output stored for each format and
+// falling through for the rest.
+type perFormat map[fmter.Format]string
+
+func (p perFormat) Format(f fmter.Format) ([]byte, error) {
+	if s, ok := p[f]; ok {
+		return []byte(s), nil
+	}
+	return nil, nil
+}
+
+func TestWriteFormatterKeepsOrder(t *testing.T) {
+	t.Parallel()
+	custom := perFormat{
+		fmter.JSON:     `{"custom": true}`,
+		fmter.CSV:      "\"Bob, Jr\",99\n",
+		fmter.Table:    "Bob Builder\t99\n",
+		fmter.JSONL:    "{\"custom\":true}\n",
+		fmter.Plain:    "custom\n",
+		fmter.Vertical: "Bob\t99\n",
+	}
+	items := []any{headedRow{basicRow{Name: "Alice", Age: "30"}}, custom, headedRow{basicRow{Name: "Carol", Age: "35"}}}
+	tests := []struct {
+		format fmter.Format
+		want   string
+	}{
+		{fmter.JSON, `[{"Name":"Alice","Age":"30"},{"custom":true},{"Name":"Carol","Age":"35"}]` + "\n"},
+		{fmter.CSV, "Name,Age\nAlice,30\n\"Bob, Jr\",99\nCarol,35\n"},
+		{fmter.Table, "Name         Age\n-----------  ---\nAlice        30\nBob Builder  99\nCarol        35\n"},
+		{fmter.JSONL, `{"Name":"Alice","Age":"30"}` + "\n" + `{"custom":true}` + "\n" + `{"Name":"Carol","Age":"35"}` + "\n"},
+		{fmter.Plain, "{{Alice 30}}\ncustom\n{{Carol 35}}\n"},
+		{fmter.Vertical, "-[ RECORD 1 ]-\nName: Alice\nAge:  30\n-[ RECORD 2 ]-\nName: Bob\nAge:  99\n-[ RECORD 3 ]-\nName: Carol\nAge:  35\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			t.Parallel()
+			var buf bytes.Buffer
+			err := fmter.WriteWith(&buf, tt.format, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestWriteFormatterKeepsOrderElements(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	items := []any{tmplItem{Name: "Alice"}, perFormat{fmter.YAML: "custom: [1, 2]\n"}, perFormat{fmter.YAML: ""}, tmplItem{Name: "Carol"}}
+	require.NoError(t, fmter.Write(&buf, fmter.YAML, items...))
+	assert.Equal(t, "- name: Alice\n  age: 0\n- custom: [1, 2]\n- null\n- name: Carol\n  age: 0\n", buf.String())
+
+	buf.Reset()
+	custom := perFormat{fmter.List: "bob\n", fmter.ENV: "CUSTOM=1\n"}
+	items = []any{podRes{"alice", ""}, custom, podRes{"carol", ""}}
+	require.NoError(t, fmter.Write(&buf, fmter.List, items...))
+	assert.Equal(t, "alice\nbob\ncarol\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.Write(&buf, fmter.ENV, items...))
+	assert.Equal(t, "NAME=alice\n\nCUSTOM=1\n\nNAME=carol\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.Write(&buf, fmter.JSON, any(perFormat{fmter.JSON: `{"only": 1}`})))
+	assert.Equal(t, `{"only":1}`+"\n", buf.String())
+}
+
+func TestWriteFormatterRowParticipates(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	items := []any{headedRow{basicRow{Name: "Alice", Age: "30"}}, perFormat{fmter.CSV: "Bob;99"}}
+	opts := []fmter.Option{fmter.WithDelimiter(';'), fmter.WithColumns("age")}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CSV, opts, items...))
+	assert.Equal(t, "Age\n30\n99\n", buf.String())
+
+	buf.Reset()
+	// Items supplying their own row need no Rower.
+	items = []any{headedRow{basicRow{Name: "Alice", Age: "30"}}, perFormat{fmter.Table: "Bob\t99"}}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...))
+	assert.Equal(t, "Name   Age\n-----  ---\nAlice  30\nBob    99\n", buf.String())
+}
+
+func TestWriteFormatterInvalidOutput(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		format fmter.Format
+		output string
+		want   string
+	}{
+		{fmter.JSON, `{"open":`, "Formatter output for item 1 is not valid JSON"},
+		{fmter.YAML, "a: [", "Formatter output for item 1:"},
+		{fmter.CSV, `"unterminated`, "Formatter output for item 1:"},
+		{fmter.CSV, "a,b\nc,d\n", "Formatter output for item 1 must be one CSV record, got 2"},
+	}
+	for _, tt := range tests {
+		items := []any{headedRow{basicRow{Name: "Alice", Age: "30"}}, perFormat{tt.format: tt.output}}
+		err := fmter.Write(io.Discard, tt.format, items...)
+		require.ErrorIs(t, err, fmter.ErrInvalidFormat, tt.format)
+		assert.Contains(t, err.Error(), tt.want, tt.format)
+	}
+}
+
+func TestWriteFormatterRuns(t *testing.T) {
+	t.Parallel()
+	f := fmter.Format("upper-runs")
+	require.NoError(t, fmter.RegisterFormat(f, renderUpper))
+	custom := perFormat{f: "custom\n", fmter.GoTemplate("{{.Name}}"): "custom\n"}
+	items := []any{tmplItem{Name: "a"}, tmplItem{Name: "b"}, custom, custom, tmplItem{Name: "c"}}
+
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, f, items...))
+	assert.Equal(t, "{A 0}\n{B 0}\ncustom\ncustom\n{C 0}\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.Write(&buf, fmter.GoTemplate("{{.Name}}"), items...))
+	assert.Equal(t, "a\nb\ncustom\ncustom\nc\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.WriteIter(&buf, fmter.GoTemplate("{{.Name}}"), slices.Values(items)))
+	assert.Equal(t, "a\nb\ncustom\ncustom\nc\n", buf.String())
+
+	for n := range 3 {
+		err := fmter.Write(&failAfterN{n: n}, f, items...)
+		require.ErrorIs(t, err, errWriteFailed, n)
+	}
+	err := fmter.Write(&failAfterN{n: 4}, f, items...)
+	require.ErrorIs(t, err, errWriteFailed)
+}
+
+func TestWriteFormatterVerbatimWriteError(t *testing.T) {
+	t.Parallel()
+	custom := perFormat{fmter.ENV: "A=1\n", fmter.JSONL: "{}\n", fmter.Plain: "x\n"}
+	for _, f := range []fmter.Format{fmter.ENV, fmter.JSONL, fmter.Plain} {
+		err := fmter.Write(&errWriter{}, f, any(custom), any(custom))
+		require.ErrorIs(t, err, errWriteFailed, f)
+	}
+}
+
+func TestWriteIterFormatterKeepsOrder(t *testing.T) {
+	t.Parallel()
+	custom := perFormat{
+		fmter.JSON:  `{"custom": true}`,
+		fmter.CSV:   "\"Bob, Jr\",99\n",
+		fmter.Table: "Bob Builder\t99\n",
+		fmter.JSONL: "{\"custom\":true}\n",
+		fmter.Plain: "custom\n",
+	}
+	items := []any{headedRow{basicRow{Name: "Alice", Age: "30"}}, custom, headedRow{basicRow{Name: "Carol", Age: "35"}}}
+	tests := []struct {
+		format fmter.Format
+		opts   []fmter.Option
+		want   string
+	}{
+		{fmter.JSON, nil, `[{"Name":"Alice","Age":"30"}` + "\n," + `{"custom":true}` + "\n," + `{"Name":"Carol","Age":"35"}` + "\n]\n"},
+		{fmter.CSV, nil, "Name,Age\nAlice,30\n\"Bob, Jr\",99\nCarol,35\n"},
+		{fmter.JSONL, nil, `{"Name":"Alice","Age":"30"}` + "\n" + `{"custom":true}` + "\n" + `{"Name":"Carol","Age":"35"}` + "\n"},
+		{fmter.Plain, nil, "{{Alice 30}}\ncustom\n{{Carol 35}}\n"},
+		{fmter.Table, []fmter.Option{fmter.WithStreamingTable(1, fmter.OverflowRepaginate), fmter.WithBorder(fmter.BorderNone)}, "Name   Age\n-----  ---\nAlice  30\n\nName         Age\n-----------  ---\nBob Builder  99\nCarol        35\n"},
+		{fmter.Table, []fmter.Option{fmter.WithStreamingTable(2, fmter.OverflowTruncate), fmter.WithBorder(fmter.BorderNone)}, "Name         Age\n-----------  ---\nAlice        30\nBob Builder  99\nCarol        35\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, fmter.WriteIter(&buf, tt.format, slices.Values(items), tt.opts...), tt.format)
+		assert.Equal(t, tt.want, buf.String(), tt.format)
+	}
+
+	var buf bytes.Buffer
+	f := fmter.JSONPath("{.Name}")
+	require.NoError(t, fmter.WriteIter(&buf, f, slices.Values([]any{items[0], perFormat{f: "custom\n"}})))
+	assert.Equal(t, "Alice\ncustom\n", buf.String())
+}
+
+func TestWriteIterFormatterErrors(t *testing.T) {
+	t.Parallel()
+	stream := fmter.WithStreamingTable(1, fmter.OverflowTruncate)
+	for _, opts := range [][]fmter.Option{nil, {stream}} {
+		for _, f := range []fmter.Format{fmter.JSON, fmter.CSV, fmter.TSV, fmter.JSONL, fmter.Plain, fmter.Table, fmter.JSONPath("{.Name}")} {
+			err := fmter.WriteIter(io.Discard, f, slices.Values([]any{formatterError{}}), opts...)
+			require.EqualError(t, err, "format error", f)
+			err = fmter.WriteIter(io.Discard, f, slices.Values([]any{headedRow{basicRow{Name: "Alice"}}, formatterError{}}), opts...)
+			require.EqualError(t, err, "format error", f)
+		}
+	}
+
+	err := fmter.WriteIter(io.Discard, fmter.JSON, slices.Values([]any{perFormat{fmter.JSON: "{"}}))
+	require.ErrorIs(t, err, fmter.ErrInvalidFormat)
+	err = fmter.WriteIter(io.Discard, fmter.CSV, slices.Values([]any{perFormat{fmter.CSV: `"x`}}))
+	require.ErrorIs(t, err, fmter.ErrInvalidFormat)
+
+	items := []any{headedRow{basicRow{Name: "Alice"}}, perFormat{fmter.CSV: "Bob\n", fmter.TSV: "Bob\n"}}
+	for _, f := range []fmter.Format{fmter.CSV, fmter.TSV} {
+		for n := range 2 {
+			err := fmter.WriteIter(&failAfterN{n: n}, f, slices.Values(items))
+			require.ErrorIs(t, err, errWriteFailed, f)
+		}
+	}
+}
+
+// --- Fitting tables to a width ---
+
+type fitRow struct{ name, desc, status string }
+
+func (r fitRow) Row() []string  { return []string{r.name, r.desc, r.status} }
+func (fitRow) Header() []string { return []string{"Name", "Description", "Status"} }
+
+type fittedRow struct{ fitRow }
+
+func (fittedRow) Fits() []fmter.Fit {
+	return []fmter.Fit{{Priority: 1, Min: 4}, {Priority: 0, Wrap: true}, {Priority: 2}}
+}
+
+func (fittedRow) NumberHeader() string { return "#" }
+
+func TestWriteTableMaxWidth(t *testing.T) {
+	t.Parallel()
+	items := []fitRow{
+		{"web", "serves the public website", "Running"},
+		{"database", "primary storage", "Pending"},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderASCII), fmter.WithMaxWidth(40)}, items...)
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"+----------+-----------------+---------+\n"+
+		"| Name     | Description     | Status  |\n"+
+		"+----------+-----------------+---------+\n"+
+		"| web      | serves the p... | Running |\n"+
+		"| database | primary storage | Pending |\n"+
+		"+----------+-----------------+---------+\n", buf.String())
+
+	// Widths of 0 or less, or wide enough already, leave the table as is.
+	want, err := fmter.Marshal(fmter.Table, items...)
+	require.NoError(t, err)
+	for _, width := range []int{0, -1, 80} {
+		got, err := fmter.MarshalWith(fmter.Table, []fmter.Option{fmter.WithMaxWidth(width)}, items...)
+		require.NoError(t, err)
+		assert.Equal(t, string(want), string(got), width)
+	}
+}
+
+func TestWriteTableMaxWidthFitted(t *testing.T) {
+	t.Parallel()
+	rows := []fittedRow{
+		{fitRow{"web", "serves the public website", "Running"}},
+		{fitRow{"database", "primary storage", "Pending"}},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithMaxWidth(28)}, rows...)
+	require.NoError(t, err)
+	// Description has the lowest priority and wraps; the number column
+	// never shrinks.
+	assert.Equal(t, ""+
+		"#  Name      Descri  Status\n"+
+		"             ption\n"+
+		"-  --------  ------  -------\n"+
+		"1  web       serves  Running\n"+
+		"             the\n"+
+		"             public\n"+
+		"             websit\n"+
+		"             e\n"+
+		"2  database  primar  Pending\n"+
+		"             y\n"+
+		"             storag\n"+
+		"             e\n", buf.String())
+
+	// A width no table fits in leaves every column at its minimum.
+	buf.Reset()
+	err = fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithMaxWidth(1)}, rows[1:]...)
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"#  Name  Des  Sta\n"+
+		"         cri\n"+
+		"         pti\n"+
+		"         on\n"+
+		"-  ----  ---  ---\n"+
+		"1  d...  pri  Pen\n"+
+		"         mar\n"+
+		"         y\n"+
+		"         sto\n"+
+		"         rag\n"+
+		"         e\n", buf.String())
+}
+
+func TestWriteIterStreamingTableMaxWidth(t *testing.T) {
+	t.Parallel()
+	items := []fitRow{
+		{"web", "serves the public website", "Running"},
+		{"database", "primary storage", "Pending"},
+		{"cache", "an in-memory cache with a long description", "Running"},
+	}
+	opts := []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithMaxWidth(30), fmter.WithStreamingTable(1, fmter.OverflowRepaginate)}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteIter(&buf, fmter.Table, slices.Values(items), opts...))
+	// The last row only widens a column that is already shrunk, so it
+	// doesn't start another table.
+	assert.Equal(t, ""+
+		"Name  Description      Status\n"+
+		"----  ---------------  -------\n"+
+		"web   serves the p...  Running\n"+
+		"\n"+
+		"Name      Description  Status\n"+
+		"--------  -----------  -------\n"+
+		"database  primary ...  Pending\n"+
+		"cache     an in-me...  Running\n", buf.String())
+}
+
+// --- Themes ---
+
+func tag(name string) func(string) string {
+	return func(s string) string { return "<" + name + ">" + s + "</" + name + ">" }
+}
+
+var tagTheme = fmter.Theme{
+	Border:  tag("b"),
+	Header:  tag("h"),
+	Title:   tag("t"),
+	Footer:  tag("f"),
+	Caption: tag("c"),
+}
+
+type themedRow struct{ groupedRow }
+
+func (themedRow) Theme() fmter.Theme        { return tagTheme }
+func (themedRow) Title() string             { return "People" }
+func (themedRow) Footer() []string          { return []string{"Total", "2"} }
+func (themedRow) Caption() string           { return "done" }
+func (themedRow) Border() fmter.BorderStyle { return fmter.BorderASCII }
+
+func TestWriteTableTheme(t *testing.T) {
+	t.Parallel()
+	items := []themedRow{
+		{groupedRow{headedRow{basicRow{Name: "Alice", Age: "30"}}, "A"}},
+		{groupedRow{headedRow{basicRow{Name: "Bob", Age: "35"}}, "B"}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithColor(true)}, items...))
+	assert.Equal(t, ""+
+		"<b>+-------------+</b>\n"+
+		"<b>|</b> <t>  People   </t> <b>|</b>\n"+
+		"<b>+-------+-----+</b>\n"+
+		"<b>|</b> <h>Name </h> <b>|</b> <h>Age</h> <b>|</b>\n"+
+		"<b>+-------+-----+</b>\n"+
+		"<b>|</b> Alice <b>|</b> 30  <b>|</b>\n"+
+		"<b>+-------+-----+</b>\n"+
+		"<b>|</b> Bob   <b>|</b> 35  <b>|</b>\n"+
+		"<b>+-------+-----+</b>\n"+
+		"<b>|</b> <f>Total</f> <b>|</b> <f>2  </f> <b>|</b>\n"+
+		"<b>+-------+-----+</b>\n"+
+		"<c>done</c>\n", buf.String())
+
+	buf.Reset()
+	theme := tagTheme
+	theme.Separator = tag("s")
+	opts := []fmter.Option{fmter.WithColor(true), fmter.WithTheme(theme), fmter.WithBorder(fmter.BorderNone)}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, items...))
+	assert.Equal(t, ""+
+		"<h>Name </h>  <h>Age</h>\n"+
+		"<b>-----  ---</b>\n"+
+		"Alice  30\n"+
+		"<s>-----  ---</s>\n"+
+		"Bob    35\n"+
+		"<b>-----  ---</b>\n"+
+		"<f>Total</f>  <f>2  </f>\n"+
+		"<c>done</c>\n", buf.String())
+}
+
+func TestWriteTableThemeDisabled(t *testing.T) {
+	t.Parallel()
+	item := themedRow{groupedRow{headedRow{basicRow{Name: "Alice", Age: "30"}}, "A"}}
+	want, err := fmter.MarshalWith(fmter.Table, []fmter.Option{fmter.WithColor(false)}, item)
+	require.NoError(t, err)
+	assert.NotContains(t, string(want), "<")
+
+	got, err := fmter.MarshalWith(fmter.Table, []fmter.Option{fmter.WithColor(false), fmter.WithTheme(fmter.ThemeDark)}, item)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+
+	got, err = fmter.MarshalWith(fmter.Table, []fmter.Option{fmter.WithColor(true)}, fitRow{"web", "serves", "Running"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(got), "\x1b")
+}
+
+func TestWriteTableBuiltinThemes(t *testing.T) {
+	t.Parallel()
+	item := themedRow{groupedRow{headedRow{basicRow{Name: "Alice", Age: "30"}}, "A"}}
+	for _, theme := range []fmter.Theme{fmter.ThemeDark, fmter.ThemeLight, fmter.ThemeMonochrome} {
+		got, err := fmter.MarshalWith(fmter.Table, []fmter.Option{fmter.WithColor(true), fmter.WithTheme(theme)}, item)
+		require.NoError(t, err)
+		assert.Contains(t, string(got), theme.Header("Name "))
+		assert.Contains(t, string(got), theme.Caption("done"))
+	}
+}
+
+// --- Per-cell styles ---
+
+type statusRow struct{ name, status string }
+
+func (r statusRow) Row() []string  { return []string{r.name, r.status} }
+func (statusRow) Header() []string { return []string{"Name", "Status"} }
+func (statusRow) Styles() []func(string) string {
+	return []func(string) string{nil, tag("col")}
+}
+
+func (statusRow) CellStyle(col int, value string) fmter.Style {
+	if col == 1 && value == "failed" {
+		return fmter.Style{Class: "failed", Apply: tag("red")}
+	}
+	return fmter.Style{}
+}
+
+func (r statusRow) RowStyle() fmter.Style {
+	if r.name == "db" {
+		return fmter.Style{Class: "highlight", Apply: tag("hl")}
+	}
+	return fmter.Style{}
+}
+
+type numberedStatusRow struct{ statusRow }
+
+func (numberedStatusRow) NumberHeader() string { return "#" }
+
+func TestWriteTableCellStyles(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	items := []statusRow{{"web", "ok"}, {"api", "failed"}, {"db", "failed"}}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...))
+	// Cell styles replace the column style; row styles wrap every cell.
+	assert.Equal(t, ""+
+		"Name  <col>Status</col>\n"+
+		"----  ------\n"+
+		"web   <col>ok    </col>\n"+
+		"api   <red>failed</red>\n"+
+		"<hl>db  </hl>  <hl><red>failed</red></hl>\n", buf.String())
+
+	buf.Reset()
+	rows := []numberedStatusRow{{items[1]}, {items[2]}}
+	opts := []fmter.Option{fmter.WithBorder(fmter.BorderASCII), fmter.WithColumns("status")}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, rows...))
+	assert.Equal(t, ""+
+		"+---+--------+\n"+
+		"| # | <col>Status</col> |\n"+
+		"+---+--------+\n"+
+		"| 1 | <red>failed</red> |\n"+
+		"| <hl>2</hl> | <hl><red>failed</red></hl> |\n"+
+		"+---+--------+\n", buf.String())
+}
+
+func TestWriteHTMLCellStyles(t *testing.T) {
+	t.Parallel()
+	items := []statusRow{{"api", "failed"}, {"db", "failed"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.HTML, []fmter.Option{fmter.WithColumns("status", "name"), fmter.WithAlignments(fmter.AlignRight)}, items...))
+	assert.Equal(t, ""+
+		"<table>\n  <thead>\n    <tr>\n      <th>Status</th>\n      <th style=\"text-align: right\">Name</th>\n    </tr>\n  </thead>\n"+
+		"  <tbody>\n    <tr>\n      <td class=\"failed\">failed</td>\n      <td style=\"text-align: right\">api</td>\n    </tr>\n"+
+		"    <tr class=\"highlight\">\n      <td class=\"failed\">failed</td>\n      <td style=\"text-align: right\">db</td>\n    </tr>\n"+
+		"  </tbody>\n</table>\n", buf.String())
+}
+
+// --- Vertical ---
+
+type wideRow struct{ name, notes string }
+
+func (r wideRow) Row() []string  { return []string{r.name, r.notes} }
+func (wideRow) Header() []string { return []string{"Name", "Description"} }
+func (wideRow) Title() string    { return "Services" }
+func (wideRow) Styles() []func(string) string {
+	return []func(string) string{tag("b"), nil}
+}
+func (wideRow) WrapWidths() []int { return []int{0, 10} }
+
+func TestWriteVertical(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	items := []richRow{{Name: "Alice", Age: "30", Status: "active"}, {Name: "Bob", Age: "", Status: "on leave"}}
+	require.NoError(t, fmter.Write(&buf, fmter.Vertical, items...))
+	// Empty values leave no trailing space; each separator spans its record.
+	assert.Equal(t, ""+
+		"People\n"+
+		"-[ RECORD 1 ]-\n"+
+		"Name:   Alice\n"+
+		"Age:    30\n"+
+		"Status: active\n"+
+		"-[ RECORD 2 ]---\n"+
+		"Name:   Bob\n"+
+		"Age:\n"+
+		"Status: on leave\n", buf.String())
+
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithColumns("status", "name"), fmter.WithTitle("")}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Vertical, opts, items[0]))
+	assert.Equal(t, "-[ RECORD 1 ]-\nStatus: active\nName:   Alice\n", buf.String())
+}
+
+func TestWriteVerticalNoHeader(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Vertical, basicRow{Name: "Alice", Age: "30"}))
+	assert.Equal(t, "-[ RECORD 1 ]-\n1: Alice\n2: 30\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Vertical, []fmter.Option{fmter.WithNoHeaders()}, headedRow{basicRow{Name: "Alice", Age: "30"}}))
+	assert.Equal(t, "-[ RECORD 1 ]-\n1: Alice\n2: 30\n", buf.String())
+}
+
+func TestWriteVerticalWrappedStyled(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Vertical, wideRow{"api", "public HTTP gateway"}))
+	assert.Equal(t, ""+
+		"Services\n"+
+		"-[ RECORD 1 ]-------\n"+
+		"Name:        <b>api</b>\n"+
+		"Description: public\n"+
+		"             HTTP\n"+
+		"             gateway\n", buf.String())
+}
+
+func TestWriteVerticalEmpty(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write[richRow](&buf, fmter.Vertical))
+	assert.Empty(t, buf.String())
+}
+
+func TestWriteVerticalErrors(t *testing.T) {
+	t.Parallel()
+	err := fmter.WriteWith(io.Discard, fmter.Vertical, []fmter.Option{fmter.WithColumns("nope")}, richRow{Name: "Alice"})
+	require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+	err = fmter.WriteIter(io.Discard, fmter.Vertical, slices.Values([]richRow{{Name: "Alice"}}), fmter.WithColumns("nope"))
+	require.ErrorIs(t, err, fmter.ErrUnknownColumn)
+
+	err = fmter.Write(io.Discard, fmter.Vertical, []any{richRow{Name: "Alice"}, 42}...)
+	var mie *fmter.MissingInterfaceError
+	require.ErrorAs(t, err, &mie)
+	assert.Equal(t, 1, mie.Index)
+
+	require.ErrorIs(t, fmter.Write(&errWriter{}, fmter.Vertical, richRow{Name: "Alice"}), errWriteFailed)
+	require.ErrorIs(t, fmter.WriteIter(&errWriter{}, fmter.Vertical, slices.Values([]richRow{{Name: "Alice"}})), errWriteFailed)
+}
+
+func TestWriteIterVertical(t *testing.T) {
+	t.Parallel()
+	items := []any{richRow{Name: "Alice", Age: "30", Status: "active"}, perFormat{fmter.Vertical: "Bob\t41\tidle\n"}, richRow{Name: "Carol"}}
+	var want, got bytes.Buffer
+	require.NoError(t, fmter.Write(&want, fmter.Vertical, items...))
+	require.NoError(t, fmter.WriteIter(&got, fmter.Vertical, slices.Values(items)))
+	assert.Equal(t, want.String(), got.String())
+	assert.Contains(t, got.String(), "-[ RECORD 2 ]-\nName:   Bob\nAge:    41\nStatus: idle\n")
+	assert.True(t, fmter.IsSupported[richRow](fmter.Vertical))
+	assert.False(t, fmter.IsSupported[tmplItem](fmter.Vertical))
+}
+
+// --- Multi-line cells ---
+
+type noteRow struct{ name, note string }
+
+func (r noteRow) Row() []string  { return []string{r.name, r.note} }
+func (noteRow) Header() []string { return []string{"Name", "Note"} }
+
+func TestWriteTableEmbeddedNewlines(t *testing.T) {
+	t.Parallel()
+	items := []noteRow{{"api", "line one\nline two"}, {"web", "ok"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderASCII)}, items...))
+	assert.Equal(t, ""+
+		"+------+----------+\n"+
+		"| Name | Note     |\n"+
+		"+------+----------+\n"+
+		"| api  | line one |\n"+
+		"|      | line two |\n"+
+		"| web  | ok       |\n"+
+		"+------+----------+\n", buf.String())
+
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithStreamingTable(1, fmter.OverflowRepaginate)}
+	require.NoError(t, fmter.WriteIter(&buf, fmter.Table, slices.Values(items), opts...))
+	assert.Equal(t, "Name  Note\n----  --------\napi   line one\n      line two\nweb   ok\n", buf.String())
+}
+
+func TestWriteVerticalEmbeddedNewlines(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Vertical, noteRow{"api", "line one\nline two"}))
+	assert.Equal(t, "-[ RECORD 1 ]-\nName: api\nNote: line one\n      line two\n", buf.String())
+}
+
+// --- ANSI-colored cells ---
+
+const (
+	ansiRed   = "\x1b[31m"
+	ansiReset = "\x1b[0m"
+)
+
+func TestWriteTableANSICells(t *testing.T) {
+	t.Parallel()
+	rows := []noteRow{{ansiRed + "api" + ansiReset, "ok"}, {"web", ansiRed + "degraded service" + ansiReset}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderASCII)}, rows...))
+	// Escape sequences take no columns, so borders stay aligned.
+	assert.Equal(t, ""+
+		"+------+------------------+\n"+
+		"| Name | Note             |\n"+
+		"+------+------------------+\n"+
+		"| "+ansiRed+"api"+ansiReset+"  | ok               |\n"+
+		"| web  | "+ansiRed+"degraded service"+ansiReset+" |\n"+
+		"+------+------------------+\n", buf.String())
+
+	// Truncation keeps the escape sequences whole and closes the color.
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, truncatedNoteRow{rows[1]}))
+	assert.Equal(t, "Name  Note\n----  --------\nweb   "+ansiRed+"degra..."+ansiReset+"\n", buf.String())
+}
+
+type truncatedNoteRow struct{ noteRow }
+
+func (truncatedNoteRow) MaxWidths() []int { return []int{0, 8} }
+
+type wrappedNoteRow struct{ noteRow }
+
+func (wrappedNoteRow) WrapWidths() []int { return []int{0, 8} }
+
+func TestWriteTableANSIWrapped(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	row := wrappedNoteRow{noteRow{"web", ansiRed + "degraded service" + ansiReset}}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, row))
+	// Each wrapped line is colored on its own.
+	assert.Equal(t, ""+
+		"Name  Note\n"+
+		"----  ----------------\n"+
+		"web   "+ansiRed+"degraded"+ansiReset+"\n"+
+		"      "+ansiRed+"service"+ansiReset+"\n", buf.String())
+}
+
+// --- Footer aggregates ---
+
+type usageRow struct{ name, cpu, mem, uptime, zone string }
+
+func (r usageRow) Row() []string  { return []string{r.name, r.cpu, r.mem, r.uptime, r.zone} }
+func (usageRow) Header() []string { return []string{"Name", "CPU", "Memory", "Uptime", "Zone"} }
+func (usageRow) Footer() []string { return []string{"Total", "", "", "", ""} }
+func (usageRow) Aggregates() []fmter.Aggregate {
+	return []fmter.Aggregate{fmter.AggregateNone, fmter.AggregateAvg, fmter.AggregateSum, fmter.AggregateMax, fmter.AggregateDistinct}
+}
+
+func TestWriteTableAggregates(t *testing.T) {
+	t.Parallel()
+	items := []usageRow{
+		{"api", "20%", "1.5 GiB", "3h0m0s", "a"},
+		{"web", "45%", "512 MiB", "26h0m0s", "b"},
+		{"db", "70%", "2 GiB", "90m", "a"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...))
+	want := "" +
+		"Name   CPU  Memory   Uptime   Zone\n" +
+		"-----  ---  -------  -------  ----\n" +
+		"api    20%  1.5 GiB  3h0m0s   a\n" +
+		"web    45%  512 MiB  26h0m0s  b\n" +
+		"db     70%  2 GiB    90m      a\n" +
+		"-----  ---  -------  -------  ----\n" +
+		"Total  45%  4 GiB    26h0m0s  2\n"
+	assert.Equal(t, want, buf.String())
+
+	// Streaming tables aggregate every row, not just the sample.
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithStreamingTable(2, fmter.OverflowTruncate)}
+	require.NoError(t, fmter.WriteIter(&buf, fmter.Table, slices.Values(items), opts...))
+	assert.Equal(t, want, buf.String())
+}
+
+func TestWriteTableAggregatesOptions(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	opts := []fmter.Option{
+		fmter.WithBorder(fmter.BorderNone),
+		fmter.WithColumns("cpu", "name"),
+		fmter.WithAggregates(fmter.AggregateCount, fmter.AggregateMin),
+	}
+	rows := []numberedUsageRow{
+		{usageRow{"web", "45%", "512 MiB", "26h0m0s", "b"}},
+		{usageRow{"api", "20%", "1.5 GiB", "3h0m0s", "a"}},
+	}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, rows...))
+	assert.Equal(t, ""+
+		"#  CPU  Name\n"+
+		"-  ---  ----\n"+
+		"1  45%  web\n"+
+		"2  20%  api\n"+
+		"-  ---  ----\n"+
+		"   20%  2\n", buf.String())
+}
+
+type numberedUsageRow struct{ usageRow }
+
+func (numberedUsageRow) NumberHeader() string { return "#" }
+
+func TestWriteAggregatesCSV(t *testing.T) {
+	t.Parallel()
+	items := []usageRow{
+		{"api", "20%", "1.5 GiB", "3h0m0s", "a"},
+		{"web", "45%", "512 MiB", "26h0m0s", "b"},
+		{"db", "70%", "2 GiB", "90m", "a"},
+	}
+	want := "Name,CPU,Memory,Uptime,Zone\n" +
+		"api,20%,1.5 GiB,3h0m0s,a\n" +
+		"web,45%,512 MiB,26h0m0s,b\n" +
+		"db,70%,2 GiB,90m,a\n" +
+		"Total,45%,4 GiB,26h0m0s,2\n"
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.CSV, items...))
+	assert.Equal(t, want, buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.WriteIter(&buf, fmter.CSV, slices.Values(items)))
+	assert.Equal(t, want, buf.String())
+
+	// Without aggregates there is no footer row.
+	buf.Reset()
+	require.NoError(t, fmter.WriteIter(&buf, fmter.CSV, slices.Values(items[:1]), fmter.WithAggregates(fmter.AggregateNone)))
+	assert.Equal(t, "Name,CPU,Memory,Uptime,Zone\napi,20%,1.5 GiB,3h0m0s,a\n", buf.String())
+
+	require.ErrorIs(t, fmter.Write(&failAfterN{}, fmter.CSV, items...), errWriteFailed)
+	require.ErrorIs(t, fmter.WriteIter(&failAfterN{n: 4}, fmter.CSV, slices.Values(items)), errWriteFailed)
+}
+
+func TestWriteAggregatesMarkdown(t *testing.T) {
+	t.Parallel()
+	items := []usageRow{{"api", "20%", "1.5 GiB", "3h0m0s", "a"}, {"web", "45%", "512 MiB", "26h0m0s", "b"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Markdown, []fmter.Option{fmter.WithColumns("name", "memory")}, items...))
+	assert.Equal(t, ""+
+		"| Name      | Memory    |\n"+
+		"| --------- | --------- |\n"+
+		"| api       | 1.5 GiB   |\n"+
+		"| web       | 512 MiB   |\n"+
+		"| **Total** | **2 GiB** |\n", buf.String())
+}
+
+func TestWriteAggregatesHTML(t *testing.T) {
+	t.Parallel()
+	items := []usageRow{{name: "api", zone: "a"}, {name: "web", zone: "b"}, {name: "db", zone: "a"}}
+	var buf bytes.Buffer
+	opts := []fmter.Option{fmter.WithColumns("zone"), fmter.WithNoHeaders()}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.HTML, opts, items...))
+	assert.Equal(t, ""+
+		"<table>\n  <tbody>\n"+
+		"    <tr>\n      <td>a</td>\n    </tr>\n"+
+		"    <tr>\n      <td>b</td>\n    </tr>\n"+
+		"    <tr>\n      <td>a</td>\n    </tr>\n"+
+		"  </tbody>\n  <tfoot>\n    <tr>\n      <td>2</td>\n    </tr>\n  </tfoot>\n</table>\n", buf.String())
+
+	// Aggregates add a footer to items that are not Footered.
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.HTML, []fmter.Option{fmter.WithAggregates(fmter.AggregateCount)}, basicRow{Name: "a"}, basicRow{Name: "b"}))
+	assert.Contains(t, buf.String(), "<tfoot>\n    <tr>\n      <td>2</td>\n    </tr>\n  </tfoot>")
+}
+
+// --- Group headers and subtotals ---
+
+type zoneRow struct{ name, zone, cpu string }
+
+func (r zoneRow) Row() []string                 { return []string{r.name, r.cpu} }
+func (zoneRow) Header() []string                { return []string{"Name", "CPU"} }
+func (r zoneRow) Group() string                 { return r.zone }
+func (zoneRow) GroupHeader(group string) string { return "Zone " + group }
+func (zoneRow) Subtotals() []fmter.Aggregate {
+	return []fmter.Aggregate{fmter.AggregateNone, fmter.AggregateSum}
+}
+
+func TestWriteTableGroupHeaders(t *testing.T) {
+	t.Parallel()
+	items := []zoneRow{{"web", "a", "10"}, {"api", "a", "20"}, {"db", "b", "5"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Table, items...))
+	// Group header rows span the table, so the lines around them close
+	// and reopen the columns.
+	assert.Equal(t, ""+
+		"╭──────┬─────╮\n"+
+		"│ Name │ CPU │\n"+
+		"├──────┴─────┤\n"+
+		"│ Zone a     │\n"+
+		"├──────┬─────┤\n"+
+		"│ web  │ 10  │\n"+
+		"│ api  │ 20  │\n"+
+		"├──────┼─────┤\n"+
+		"│      │ 30  │\n"+
+		"├──────┴─────┤\n"+
+		"│ Zone b     │\n"+
+		"├──────┬─────┤\n"+
+		"│ db   │ 5   │\n"+
+		"├──────┼─────┤\n"+
+		"│      │ 5   │\n"+
+		"╰──────┴─────╯\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithNoHeaders(), fmter.WithTitle("Usage"), fmter.WithSubtotals(fmter.AggregateNone)}, items[2]))
+	assert.Equal(t, ""+
+		"╭────────╮\n"+
+		"│ Usage  │\n"+
+		"├────────┤\n"+
+		"│ Zone b │\n"+
+		"├────┬───┤\n"+
+		"│ db │ 5 │\n"+
+		"╰────┴───╯\n", buf.String())
+}
+
+func TestWriteTableGroupHeadersPlain(t *testing.T) {
+	t.Parallel()
+	items := []zoneRow{{"web", "a", "10"}, {"api", "a", "20"}, {"db", "b", "5"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...))
+	assert.Equal(t, ""+
+		"Name  CPU\n"+
+		"----  ---\n"+
+		"Zone a\n"+
+		"web   10\n"+
+		"api   20\n"+
+		"----  ---\n"+
+		"      30\n"+
+		"\n"+
+		"Zone b\n"+
+		"db    5\n"+
+		"----  ---\n"+
+		"      5\n", buf.String())
+}
+
+func TestWriteTableGroupHeadersOptions(t *testing.T) {
+	t.Parallel()
+	items := []groupedRow{
+		{headedRow{basicRow{Name: "Alice", Age: "30"}}, "A"},
+		{headedRow{basicRow{Name: "Bob", Age: "25"}}, "A"},
+		{headedRow{basicRow{Name: "Carol", Age: "35"}}, "B"},
+	}
+	var buf bytes.Buffer
+	opts := []fmter.Option{
+		fmter.WithBorder(fmter.BorderASCII),
+		fmter.WithGroupHeaders(true),
+		fmter.WithSubtotals(fmter.AggregateCount, fmter.AggregateAvg),
+		fmter.WithPageSize(1),
+	}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, items...))
+	// Without GroupHeaded the header is the group key, and it repeats with
+	// the column header on every page.
+	assert.Equal(t, ""+
+		"+-------+------+\n"+
+		"| Name  | Age  |\n"+
+		"+-------+------+\n"+
+		"| A            |\n"+
+		"+-------+------+\n"+
+		"| Alice | 30   |\n"+
+		"+-------+------+\n"+
+		"| Name  | Age  |\n"+
+		"+-------+------+\n"+
+		"| A            |\n"+
+		"+-------+------+\n"+
+		"| Bob   | 25   |\n"+
+		"+-------+------+\n"+
+		"| 2     | 27.5 |\n"+
+		"+-------+------+\n"+
+		"| Name  | Age  |\n"+
+		"+-------+------+\n"+
+		"| B            |\n"+
+		"+-------+------+\n"+
+		"| Carol | 35   |\n"+
+		"+-------+------+\n"+
+		"| 1     | 35   |\n"+
+		"+-------+------+\n", buf.String())
+
+	// WithGroupHeaders(false) falls back to separators.
+	buf.Reset()
+	opts = []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithGroupHeaders(false), fmter.WithSubtotals(fmter.AggregateNone)}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, zoneRow{"web", "a", "10"}, zoneRow{"db", "b", "5"}))
+	assert.Equal(t, "Name  CPU\n----  ---\nweb   10\n----  ---\ndb    5\n", buf.String())
+
+	// Group options do nothing for items that are not Grouped.
+	buf.Reset()
+	opts = []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithGroupHeaders(true), fmter.WithSubtotals(fmter.AggregateCount)}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, headedRow{basicRow{Name: "Alice", Age: "30"}}))
+	assert.Equal(t, "Name   Age\n-----  ---\nAlice  30\n", buf.String())
+}
+
+// Subtotals describe the item's own columns, so custom columns ignore them.
+func TestWriteSubtotalsCustomColumns(t *testing.T) {
+	t.Parallel()
+	items := []zoneRow{{"web", "a", "10"}, {"db", "b", "5"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CustomColumns("ZONE:.zone"), []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithGroupHeaders(false)}, items...))
+	assert.Equal(t, "ZONE\n------\n<none>\n------\n<none>\n", buf.String())
+}
+
+type numberedZoneRow struct{ zoneRow }
+
+func (numberedZoneRow) NumberHeader() string { return "#" }
+
+func TestWriteTableSubtotalsStreaming(t *testing.T) {
+	t.Parallel()
+	rows := []numberedZoneRow{{zoneRow{"web", "a", "10"}}, {zoneRow{"api", "a", "20"}}, {zoneRow{"db", "b", "5"}}}
+	opts := []fmter.Option{fmter.WithBorder(fmter.BorderNone), fmter.WithGroupHeaders(false), fmter.WithStreamingTable(1, fmter.OverflowTruncate)}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteIter(&buf, fmter.Table, slices.Values(rows), opts...))
+	assert.Equal(t, ""+
+		"      #  Name  CPU\n"+
+		"-------  ----  ---\n"+
+		"      1  web   10\n"+
+		"      2  api   20\n"+
+		"-------  ----  ---\n"+
+		"               30\n"+
+		"-------  ----  ---\n"+
+		"      3  db    5\n"+
+		"-------  ----  ---\n"+
+		"               5\n", buf.String())
+}
+
+func TestWriteGroupsMarkdown(t *testing.T) {
+	t.Parallel()
+	items := []zoneRow{{"web", "a", "10"}, {"api", "a", "20"}, {"db", "b", "5"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Markdown, items...))
+	assert.Equal(t, ""+
+		"| Name       | CPU    |\n"+
+		"| ---------- | ------ |\n"+
+		"| **Zone a** |        |\n"+
+		"| web        | 10     |\n"+
+		"| api        | 20     |\n"+
+		"|            | **30** |\n"+
+		"| **Zone b** |        |\n"+
+		"| db         | 5      |\n"+
+		"|            | **5**  |\n", buf.String())
+}
+
+func TestWriteGroupsHTML(t *testing.T) {
+	t.Parallel()
+	items := []zoneRow{{"api", "a", "20"}, {"db", "b", "5"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.HTML, []fmter.Option{fmter.WithNoHeaders(), fmter.WithAlignments(fmter.AlignLeft, fmter.AlignRight)}, items...))
+	assert.Equal(t, ""+
+		"<table>\n"+
+		"  <tbody>\n"+
+		"    <tr>\n      <th colspan=\"2\" scope=\"rowgroup\">Zone a</th>\n    </tr>\n"+
+		"    <tr>\n      <td>api</td>\n      <td style=\"text-align: right\">20</td>\n    </tr>\n"+
+		"    <tr class=\"subtotal\">\n      <td></td>\n      <td style=\"text-align: right\">20</td>\n    </tr>\n"+
+		"  </tbody>\n"+
+		"  <tbody>\n"+
+		"    <tr>\n      <th colspan=\"2\" scope=\"rowgroup\">Zone b</th>\n    </tr>\n"+
+		"    <tr>\n      <td>db</td>\n      <td style=\"text-align: right\">5</td>\n    </tr>\n"+
+		"    <tr class=\"subtotal\">\n      <td></td>\n      <td style=\"text-align: right\">5</td>\n    </tr>\n"+
+		"  </tbody>\n"+
+		"</table>\n", buf.String())
+}
+
+func TestWriteGroupsErrors(t *testing.T) {
+	t.Parallel()
+	items := []zoneRow{{"web", "a", "10"}, {"api", "a", "20"}, {"db", "b", "5"}}
+	for _, f := range []fmter.Format{fmter.Table, fmter.HTML} {
+		for n := range 40 {
+			err := fmter.Write(&failAfterN{n: n}, f, items...)
+			if err == nil {
+				break
+			}
+			require.ErrorIs(t, err, errWriteFailed, "%s after %d writes", f, n)
+		}
+	}
+	opts := []fmter.Option{fmter.WithBorder(fmter.BorderNone)}
+	for n := range 20 {
+		err := fmter.WriteWith(&failAfterN{n: n}, fmter.Table, opts, items...)
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, errWriteFailed)
+	}
+}
+
+// --- Tree ---
+
+type treeNode struct {
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status,omitempty" yaml:"status,omitempty"`
+	kids   []treeNode
+}
+
+func (n treeNode) Row() []string  { return []string{n.Name, n.Status} }
+func (treeNode) Header() []string { return []string{"Name", "Status"} }
+func (n treeNode) Children() []any {
+	children := make([]any, len(n.kids))
+	for i, kid := range n.kids {
+		children[i] = kid
+	}
+	return children
+}
+
+// folder is a tree node without a row, labeled by its String method.
+type folder struct {
+	name string
+	kids []any
+}
+
+func (f folder) String() string  { return f.name }
+func (f folder) Children() []any { return f.kids }
+
+func TestWriteTree(t *testing.T) {
+	t.Parallel()
+	items := []treeNode{
+		{Name: "shop", kids: []treeNode{
+			{Name: "api", Status: "running", kids: []treeNode{
+				{Name: "api-1", Status: "running"},
+				{Name: "api-2", Status: "stopped"},
+			}},
+			{Name: "web", Status: "running"},
+		}},
+		{Name: "blog"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Tree, items...))
+	assert.Equal(t, ""+
+		"Name           Status\n"+
+		"shop\n"+
+		"├── api        running\n"+
+		"│   ├── api-1  running\n"+
+		"│   └── api-2  stopped\n"+
+		"└── web        running\n"+
+		"blog\n", buf.String())
+
+	var got bytes.Buffer
+	require.NoError(t, fmter.WriteIter(&got, fmter.Tree, slices.Values(items)))
+	assert.Equal(t, buf.String(), got.String())
+}
+
+func TestWriteTreeBorders(t *testing.T) {
+	t.Parallel()
+	src := folder{name: "src", kids: []any{
+		folder{name: "cmd", kids: []any{"main.go"}},
+		"go.mod",
+	}}
+	tests := map[fmter.BorderStyle]string{
+		fmter.BorderRounded: "src\n├── cmd\n│   └── main.go\n└── go.mod\n",
+		fmter.BorderASCII:   "src\n|-- cmd\n|   `-- main.go\n`-- go.mod\n",
+		fmter.BorderHeavy:   "src\n┣━━ cmd\n┃   ┗━━ main.go\n┗━━ go.mod\n",
+		fmter.BorderDouble:  "src\n╠══ cmd\n║   ╚══ main.go\n╚══ go.mod\n",
+		fmter.BorderNone:    "src\n  cmd\n    main.go\n  go.mod\n",
+	}
+	for border, want := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, fmter.WriteWith(&buf, fmter.Tree, []fmter.Option{fmter.WithBorder(border)}, src))
+		assert.Equal(t, want, buf.String(), border)
+	}
+}
+
+func TestWriteTreeOptions(t *testing.T) {
+	t.Parallel()
+	opts := []fmter.Option{
+		fmter.WithNoHeaders(),
+		fmter.WithAlignments(fmter.AlignLeft, fmter.AlignRight),
+		fmter.WithTheme(fmter.Theme{Border: tag("b")}),
+		fmter.WithColor(true),
+	}
+	api := treeNode{Name: "api", Status: "running", kids: []treeNode{{Name: "api-1", Status: "running"}}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Tree, opts, treeNode{Name: "shop", kids: []treeNode{api}}))
+	assert.Equal(t, ""+
+		"shop\n"+
+		"<b>└── </b>api        running\n"+
+		"<b>    └── </b>api-1  running\n", buf.String())
+
+	buf.Reset()
+	opts = []fmter.Option{fmter.WithTheme(fmter.Theme{Header: tag("h")}), fmter.WithColor(true)}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Tree, opts, treeNode{Name: "blog", Status: "up"}))
+	assert.Equal(t, "<h>Name</h>  <h>Status</h>\nblog  up\n", buf.String())
+}
+
+func TestWriteTreeFormatter(t *testing.T) {
+	t.Parallel()
+	items := []any{folder{name: "src", kids: []any{"main.go"}}, perFormat{fmter.Tree: "custom\n"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Tree, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...))
+	assert.Equal(t, "src\n  main.go\ncustom\n", buf.String())
+}
+
+func TestWriteTreeErrors(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, fmter.Write[folder](io.Discard, fmter.Tree))
+	assert.True(t, fmter.IsSupported[folder](fmter.Tree))
+	assert.False(t, fmter.IsSupported[richRow](fmter.Tree))
+
+	var mie *fmter.MissingInterfaceError
+	require.ErrorAs(t, fmter.Write(io.Discard, fmter.Tree, []any{folder{name: "src"}, "README"}...), &mie)
+	assert.Equal(t, 1, mie.Index)
+
+	require.ErrorIs(t, fmter.Write(&errWriter{}, fmter.Tree, folder{name: "src"}), errWriteFailed)
+}
+
+// labelNode is a tree node that encodes as its name alone.
+type labelNode struct {
+	name string
+	kids []any
+}
+
+func (n labelNode) Children() []any              { return n.kids }
+func (n labelNode) MarshalJSON() ([]byte, error) { return json.Marshal(n.name) }
+func (n labelNode) MarshalYAML() (any, error)    { return n.name, nil }
+
+type emptyNode struct{ kids []any }
+
+func (n emptyNode) Children() []any { return n.kids }
+
+// keptNode encodes its own children, which are not nested again.
+type keptNode struct {
+	Items []string `json:"children" yaml:"children"`
+}
+
+func (keptNode) Children() []any { return []any{"ignored"} }
+
+func TestWriteTreeJSON(t *testing.T) {
+	t.Parallel()
+	items := []treeNode{
+		{Name: "shop", kids: []treeNode{
+			{Name: "api", Status: "running", kids: []treeNode{
+				{Name: "api-1", Status: "running"},
+				{Name: "api-2", Status: "stopped"},
+			}},
+			{Name: "web", Status: "running"},
+		}},
+		{Name: "blog"},
+	}
+	tree := []fmter.Option{fmter.WithTree()}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.JSON, tree, items...))
+	assert.JSONEq(t, `[
+		{"name": "shop", "children": [
+			{"name": "api", "status": "running", "children": [
+				{"name": "api-1", "status": "running"},
+				{"name": "api-2", "status": "stopped"}
+			]},
+			{"name": "web", "status": "running"}
+		]},
+		{"name": "blog"}
+	]`, buf.String())
+
+	var got bytes.Buffer
+	require.NoError(t, fmter.WriteIter(&got, fmter.JSON, slices.Values(items), tree...))
+	assert.JSONEq(t, buf.String(), got.String())
+
+	// JSONL nests each item's children on its line.
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.JSONL, tree, items[0].kids...))
+	assert.Equal(t, ""+
+		`{"name":"api","status":"running","children":[{"name":"api-1","status":"running"},{"name":"api-2","status":"stopped"}]}`+"\n"+
+		`{"name":"web","status":"running"}`+"\n", buf.String())
+
+	// Without WithTree, items are encoded as they are.
+	for _, f := range []fmter.Format{fmter.JSON, fmter.JSONL} {
+		buf.Reset()
+		require.NoError(t, fmter.Write(&buf, f, items[1]))
+		assert.JSONEq(t, `{"name":"blog"}`, strings.Trim(buf.String(), "[]\n"), f)
+	}
+
+	// Values that are not objects are wrapped, and empty objects gain
+	// just the children.
+	buf.Reset()
+	others := []any{
+		labelNode{"src", []any{emptyNode{[]any{"x"}}}},
+		keptNode{Items: []string{"own"}},
+	}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.JSON, tree, others...))
+	assert.Equal(t, `[{"value":"src","children":[{"children":["x"]}]},{"children":["own"]}]`+"\n", buf.String())
+}
+
+func TestWriteTreeYAML(t *testing.T) {
+	t.Parallel()
+	tree := []fmter.Option{fmter.WithTree()}
+	var buf bytes.Buffer
+	api := treeNode{Name: "api", Status: "running", kids: []treeNode{{Name: "api-1", Status: "running"}}}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.YAML, tree, treeNode{Name: "shop", kids: []treeNode{api}}))
+	assert.Equal(t, ""+
+		"name: shop\n"+
+		"children:\n"+
+		"    - name: api\n"+
+		"      status: running\n"+
+		"      children:\n"+
+		"        - name: api-1\n"+
+		"          status: running\n", buf.String())
+
+	buf.Reset()
+	items := []any{
+		labelNode{"src", []any{"x"}},
+		keptNode{Items: []string{"own"}},
+	}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.YAML, tree, items...))
+	assert.Equal(t, "- value: src\n  children:\n    - x\n- children:\n    - own\n", buf.String())
+}
+
+type badNode struct{}
+
+func (badNode) MarshalJSON() ([]byte, error) { return nil, errBadNode }
+func (badNode) MarshalYAML() (any, error)    { return nil, errBadNode }
+
+var errBadNode = errors.New("bad node")
+
+func TestWriteTreeEncodeErrors(t *testing.T) {
+	t.Parallel()
+	tree := []fmter.Option{fmter.WithTree()}
+	bad := labelNode{"src", []any{labelNode{"bad", []any{badNode{}}}}}
+	for _, f := range []fmter.Format{fmter.JSON, fmter.JSONL, fmter.YAML} {
+		require.ErrorIs(t, fmter.WriteWith(io.Discard, f, tree, bad), errBadNode, f)
+	}
+	require.ErrorIs(t, fmter.WriteIter(io.Discard, fmter.JSON, slices.Values([]labelNode{bad}), tree...), errBadNode)
+}
+
+// loopNode is a tree node whose children can lead back to it.
+type loopNode struct {
+	name string
+	kids []any
+}
+
+func (n *loopNode) String() string  { return n.name }
+func (n *loopNode) Children() []any { return n.kids }
+
+// depthNode has a child one deeper than itself, without end.
+type depthNode int
+
+func (n depthNode) String() string  { return strconv.Itoa(int(n)) }
+func (n depthNode) Children() []any { return []any{n + 1} }
+
+func TestWriteTreeCycle(t *testing.T) {
+	t.Parallel()
+	root := &loopNode{name: "root"}
+	root.kids = []any{&loopNode{name: "child", kids: []any{root}}}
+	tree := []fmter.Option{fmter.WithTree()}
+	for _, f := range []fmter.Format{fmter.Tree, fmter.JSON, fmter.YAML} {
+		err := fmter.WriteWith(io.Discard, f, tree, root)
+		require.ErrorIs(t, err, fmter.ErrInvalidFormat, f)
+		assert.ErrorContains(t, err, "cycle in Children() of *fmter_test.loopNode", f)
+
+		require.ErrorIs(t, fmter.WriteWith(io.Discard, f, tree, depthNode(0)), fmter.ErrInvalidFormat, f)
+	}
+
+	// The same node may appear in separate branches.
+	leaf := &loopNode{name: "leaf"}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Tree, &loopNode{name: "root", kids: []any{leaf, leaf}}))
+	assert.Equal(t, "root\n├── leaf\n└── leaf\n", buf.String())
+}
+
+// --- Custom border sets ---
+
+var borderLight = fmter.RegisterBorder(fmter.BorderSet{
+	TopLeft: "┌", TopRight: "┐", BottomLeft: "└", BottomRight: "┘",
+	Horizontal: "─", Vertical: "│",
+	TopTee: "┬", BottomTee: "┴", LeftTee: "├", RightTee: "┤",
+	Cross: "┼",
+})
+
+type lightRow struct{ headedRow }
+
+func (lightRow) Border() fmter.BorderStyle { return borderLight }
+
+func TestRegisterBorder(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "┌", borderLight.Chars().TopLeft)
+	assert.Equal(t, "╭", fmter.BorderRounded.Chars().TopLeft)
+	assert.Equal(t, fmter.BorderSet{}, fmter.BorderNone.Chars())
+	assert.Equal(t, fmter.BorderSet{}, fmter.BorderStyle(-1).Chars())
+	assert.Equal(t, fmter.BorderSet{}, fmter.BorderStyle(1<<20).Chars())
+
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Table, lightRow{headedRow{basicRow{Name: "Alice", Age: "30"}}}))
+	assert.Equal(t, ""+
+		"┌───────┬─────┐\n"+
+		"│ Name  │ Age │\n"+
+		"├───────┼─────┤\n"+
+		"│ Alice │ 30  │\n"+
+		"└───────┴─────┘\n", buf.String())
+
+	// Tree connectors come from the set's tees, corners, and lines.
+	buf.Reset()
+	src := folder{name: "src", kids: []any{"main.go", "go.mod"}}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Tree, []fmter.Option{fmter.WithBorder(borderLight)}, src))
+	assert.Equal(t, "src\n├── main.go\n└── go.mod\n", buf.String())
+}
+
+func TestWithBorderSet(t *testing.T) {
+	t.Parallel()
+	items := []richRow{{Name: "Alice", Age: "30", Status: "active"}, {Name: "Bob", Age: "4", Status: "idle"}}
+
+	// Inner lines only.
+	inner := borderLight.Chars()
+	inner.NoFrame = true
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorderSet(inner)}, items...))
+	assert.Equal(t, ""+
+		"         People\n"+
+		"# │ Name  │ Age │ Status\n"+
+		"──┼───────┼─────┼───────\n"+
+		"1 │ Alice │  30 │ active\n"+
+		"2 │ Bob   │   4 │  idle\n"+
+		"──┼───────┼─────┼───────\n"+
+		"  │ Total │   2 │\n"+
+		"2 results\n", buf.String())
+
+	// Header underline only.
+	under := inner
+	under.NoColumns = true
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorderSet(under)}, items...))
+	assert.Equal(t, ""+
+		"       People\n"+
+		"#  Name   Age  Status\n"+
+		"─────────────────────\n"+
+		"1  Alice   30  active\n"+
+		"2  Bob      4   idle\n"+
+		"─────────────────────\n"+
+		"   Total    2\n"+
+		"2 results\n", buf.String())
+
+	// A line between every row.
+	lines := borderLight.Chars()
+	lines.RowLines = true
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorderSet(lines), fmter.WithNoHeaders()}, headedRow{basicRow{Name: "Alice", Age: "30"}}, headedRow{basicRow{Name: "Bob", Age: "4"}}))
+	assert.Equal(t, ""+
+		"┌───────┬────┐\n"+
+		"│ Alice │ 30 │\n"+
+		"├───────┼────┤\n"+
+		"│ Bob   │ 4  │\n"+
+		"└───────┴────┘\n", buf.String())
+
+	// The last of WithBorder and WithBorderSet wins.
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithBorderSet(lines), fmter.WithBorder(fmter.BorderNone)}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, headedRow{basicRow{Name: "Alice", Age: "30"}}))
+	assert.Equal(t, "Name   Age\n-----  ---\nAlice  30\n", buf.String())
+}
+
+func TestBorderSetGroups(t *testing.T) {
+	t.Parallel()
+	inner := borderLight.Chars()
+	inner.NoFrame = true
+	items := []zoneRow{{"web", "a", "10"}, {"api", "a", "20"}, {"db", "b", "5"}, {"cache", "b", "1"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorderSet(inner)}, items[:3]...))
+	assert.Equal(t, ""+
+		"Name │ CPU\n"+
+		"─────┴────\n"+
+		"Zone a\n"+
+		"─────┬────\n"+
+		"web  │ 10\n"+
+		"api  │ 20\n"+
+		"─────┼────\n"+
+		"     │ 30\n"+
+		"─────┴────\n"+
+		"Zone b\n"+
+		"─────┬────\n"+
+		"db   │ 5\n"+
+		"─────┼────\n"+
+		"     │ 5\n", buf.String())
+
+	// Row lines give way to the lines between groups.
+	lines := borderLight.Chars()
+	lines.RowLines = true
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithBorderSet(lines), fmter.WithGroupHeaders(false), fmter.WithSubtotals(fmter.AggregateNone), fmter.WithPageSize(2)}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, items...))
+	assert.Equal(t, ""+
+		"┌───────┬─────┐\n"+
+		"│ Name  │ CPU │\n"+
+		"├───────┼─────┤\n"+
+		"│ web   │ 10  │\n"+
+		"├───────┼─────┤\n"+
+		"│ api   │ 20  │\n"+
+		"├───────┼─────┤\n"+
+		"├───────┼─────┤\n"+
+		"│ Name  │ CPU │\n"+
+		"├───────┼─────┤\n"+
+		"│ db    │ 5   │\n"+
+		"├───────┼─────┤\n"+
+		"│ cache │ 1   │\n"+
+		"└───────┴─────┘\n", buf.String())
+}
+
+func TestBorderSetFitAndStream(t *testing.T) {
+	t.Parallel()
+	inner := borderLight.Chars()
+	inner.NoFrame = true
+	items := []headedRow{
+		{basicRow{Name: "Al", Age: "30"}},
+		{basicRow{Name: "Bartholomew", Age: "25"}},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorderSet(inner), fmter.WithMaxWidth(12)}, items...))
+	assert.Equal(t, ""+
+		"Name   │ Age\n"+
+		"───────┼────\n"+
+		"Al     │ 30\n"+
+		"Bar... │ 25\n", buf.String())
+
+	buf.Reset()
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithBorderSet(inner), fmter.WithStreamingTable(1, fmter.OverflowRepaginate))
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"Name │ Age\n"+
+		"─────┼────\n"+
+		"Al   │ 30\n"+
+		"\n"+
+		"Name        │ Age\n"+
+		"────────────┼────\n"+
+		"Bartholomew │ 25\n", buf.String())
+}
+
+func TestBorderSetWriteErrors(t *testing.T) {
+	t.Parallel()
+	inner := borderLight.Chars()
+	inner.NoFrame, inner.RowLines = true, true
+	items := []richRow{{Name: "Alice", Age: "30"}, {Name: "Bob", Age: "4"}}
+	for n := range 20 {
+		err := fmter.WriteWith(&failAfterN{n: n}, fmter.Table, []fmter.Option{fmter.WithBorderSet(inner)}, items...)
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, errWriteFailed, "after %d writes", n)
+	}
+	zones := []zoneRow{{"web", "a", "10"}, {"api", "a", "20"}, {"db", "b", "5"}, {"cache", "b", "1"}}
+	opts := []fmter.Option{fmter.WithBorderSet(borderLight.Chars()), fmter.WithGroupHeaders(false), fmter.WithPageSize(2)}
+	for n := range 30 {
+		err := fmter.WriteWith(&failAfterN{n: n}, fmter.Table, opts, zones...)
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, errWriteFailed, "after %d writes", n)
+	}
+}
+
+// --- Column spans and header groups ---
+
+type latencyRow struct {
+	Service, P50, P95, P99 string
+	Note                   string // replaces the latencies, spanning their columns
+}
+
+func (latencyRow) Header() []string { return []string{"Service", "p50", "p95", "p99"} }
+
+func (r latencyRow) Row() []string {
+	if r.Note != "" {
+		return []string{r.Service, r.Note, "", ""}
+	}
+	return []string{r.Service, r.P50, r.P95, r.P99}
+}
+
+func (r latencyRow) Spans() []int {
+	if r.Note != "" {
+		return []int{1, 3}
+	}
+	return nil
+}
+
+func (latencyRow) HeaderGroups() []fmter.HeaderGroup {
+	return []fmter.HeaderGroup{{Span: 1}, {Title: "Requests", Span: 3}}
+}
+
+func TestSpansTable(t *testing.T) {
+	t.Parallel()
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "batch", Note: "paused"},
+		{Service: "api", P50: "8ms", P95: "21ms", P99: "55ms"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Table, items...))
+	assert.Equal(t, ""+
+		"╭─────────┬────────────────────╮\n"+
+		"│         │      Requests      │\n"+
+		"├─────────┼──────┬──────┬──────┤\n"+
+		"│ Service │ p50  │ p95  │ p99  │\n"+
+		"├─────────┼──────┼──────┼──────┤\n"+
+		"│ web     │ 12ms │ 40ms │ 90ms │\n"+
+		"│ batch   │ paused             │\n"+
+		"│ api     │ 8ms  │ 21ms │ 55ms │\n"+
+		"╰─────────┴──────┴──────┴──────╯\n", buf.String())
+
+	// Without borders, group titles center over their columns.
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorder(fmter.BorderNone)}, items...))
+	assert.Equal(t, ""+
+		"             Requests\n"+
+		"Service  p50   p95   p99\n"+
+		"-------  ----  ----  ----\n"+
+		"web      12ms  40ms  90ms\n"+
+		"batch    paused\n"+
+		"api      8ms   21ms  55ms\n", buf.String())
+
+	// Lines above and below a spanned cell end in tees.
+	lines := borderLight.Chars()
+	lines.RowLines = true
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithBorderSet(lines), fmter.WithTitle("Latency")}, items...))
+	assert.Equal(t, ""+
+		"┌──────────────────────────────┐\n"+
+		"│           Latency            │\n"+
+		"├─────────┬────────────────────┤\n"+
+		"│         │      Requests      │\n"+
+		"├─────────┼──────┬──────┬──────┤\n"+
+		"│ Service │ p50  │ p95  │ p99  │\n"+
+		"├─────────┼──────┼──────┼──────┤\n"+
+		"│ web     │ 12ms │ 40ms │ 90ms │\n"+
+		"├─────────┼──────┴──────┴──────┤\n"+
+		"│ batch   │ paused             │\n"+
+		"├─────────┼──────┬──────┬──────┤\n"+
+		"│ api     │ 8ms  │ 21ms │ 55ms │\n"+
+		"└─────────┴──────┴──────┴──────┘\n", buf.String())
+}
+
+func TestSpansLayout(t *testing.T) {
+	t.Parallel()
+	// A cell spanning the selected columns widens them to fit.
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "batch", Note: "paused"},
+		{Service: "api", P50: "8ms", P95: "21ms", P99: "55ms"},
+		{Service: "cron", Note: "paused for the maintenance window"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithColumns("Service", "p50", "p99")}, items...))
+	assert.Equal(t, ""+
+		"╭─────────┬───────────────────────────────────╮\n"+
+		"│         │             Requests              │\n"+
+		"├─────────┼──────┬────────────────────────────┤\n"+
+		"│ Service │ p50  │ p99                        │\n"+
+		"├─────────┼──────┼────────────────────────────┤\n"+
+		"│ web     │ 12ms │ 90ms                       │\n"+
+		"│ batch   │ paused                            │\n"+
+		"│ api     │ 8ms  │ 55ms                       │\n"+
+		"│ cron    │ paused for the maintenance window │\n"+
+		"╰─────────┴───────────────────────────────────╯\n", buf.String())
+
+	// Numbering adds an ungrouped column.
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithHeaderGroups(fmter.HeaderGroup{Title: "Person", Span: 2}, fmter.HeaderGroup{Title: "State"})}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, richRow{Name: "Alice", Age: "30", Status: "active"}))
+	assert.Equal(t, ""+
+		"╭──────────────────────────╮\n"+
+		"│          People          │\n"+
+		"├───┬─────────────┬────────┤\n"+
+		"│   │   Person    │ State  │\n"+
+		"├───┼───────┬─────┼────────┤\n"+
+		"│ # │ Name  │ Age │ Status │\n"+
+		"├───┼───────┼─────┼────────┤\n"+
+		"│ 1 │ Alice │  30 │ active │\n"+
+		"├───┼───────┼─────┼────────┤\n"+
+		"│   │ Total │   2 │        │\n"+
+		"╰───┴───────┴─────┴────────╯\n"+
+		"2 results\n", buf.String())
+
+	// Header groups go with the header.
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, []fmter.Option{fmter.WithNoHeaders()}, items[:3]...))
+	assert.Equal(t, ""+
+		"╭───────┬──────┬──────┬──────╮\n"+
+		"│ web   │ 12ms │ 40ms │ 90ms │\n"+
+		"│ batch │ paused             │\n"+
+		"│ api   │ 8ms  │ 21ms │ 55ms │\n"+
+		"╰───────┴──────┴──────┴──────╯\n", buf.String())
+}
+
+func TestSpansReordered(t *testing.T) {
+	t.Parallel()
+	// A spanning cell shown after a column it covers keeps its value, and
+	// only merges with the covered columns shown after it.
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "batch", Note: "paused"},
+	}
+	opts := []fmter.Option{fmter.WithColumns("p99", "p50", "p95", "service"), fmter.WithBorder(fmter.BorderASCII)}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Table, opts, items...))
+	assert.Equal(t, ""+
+		"+--------------------+---------+\n"+
+		"|      Requests      |         |\n"+
+		"+------+------+------+---------+\n"+
+		"| p99  | p50  | p95  | Service |\n"+
+		"+------+------+------+---------+\n"+
+		"| 90ms | 12ms | 40ms | web     |\n"+
+		"|      | paused      | batch   |\n"+
+		"+------+-------------+---------+\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, fmter.WriteWith(&buf, fmter.HTML, opts, items[1]))
+	assert.Contains(t, buf.String(), "<td></td>\n      <td colspan=\"2\">paused</td>\n      <td>batch</td>")
+}
+
+func TestSpansStream(t *testing.T) {
+	t.Parallel()
+	// A spanned cell too wide for the sampled columns starts a new page.
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "cron", Note: "paused for the maintenance window"},
+	}
+	var buf bytes.Buffer
+	err := fmter.WriteIter(&buf, fmter.Table, slices.Values(items), fmter.WithStreamingTable(1, fmter.OverflowRepaginate))
+	require.NoError(t, err)
+	assert.Equal(t, ""+
+		"╭─────────┬────────────────────╮\n"+
+		"│         │      Requests      │\n"+
+		"├─────────┼──────┬──────┬──────┤\n"+
+		"│ Service │ p50  │ p95  │ p99  │\n"+
+		"├─────────┼──────┼──────┼──────┤\n"+
+		"│ web     │ 12ms │ 40ms │ 90ms │\n"+
+		"╰─────────┴──────┴──────┴──────╯\n"+
+		"╭─────────┬───────────────────────────────────╮\n"+
+		"│         │             Requests              │\n"+
+		"├─────────┼──────┬──────┬─────────────────────┤\n"+
+		"│ Service │ p50  │ p95  │ p99                 │\n"+
+		"├─────────┼──────┴──────┴─────────────────────┤\n"+
+		"│ cron    │ paused for the maintenance window │\n"+
+		"╰─────────┴───────────────────────────────────╯\n", buf.String())
+}
+
+func TestSpansHTML(t *testing.T) {
+	t.Parallel()
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "batch", Note: "paused"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.HTML, items...))
+	assert.Equal(t, ""+
+		"<table>\n"+
+		"  <thead>\n"+
+		"    <tr>\n"+
+		"      <th></th>\n"+
+		"      <th colspan=\"3\" scope=\"colgroup\">Requests</th>\n"+
+		"    </tr>\n"+
+		"    <tr>\n"+
+		"      <th>Service</th>\n"+
+		"      <th>p50</th>\n"+
+		"      <th>p95</th>\n"+
+		"      <th>p99</th>\n"+
+		"    </tr>\n"+
+		"  </thead>\n"+
+		"  <tbody>\n"+
+		"    <tr>\n"+
+		"      <td>web</td>\n"+
+		"      <td>12ms</td>\n"+
+		"      <td>40ms</td>\n"+
+		"      <td>90ms</td>\n"+
+		"    </tr>\n"+
+		"    <tr>\n"+
+		"      <td>batch</td>\n"+
+		"      <td colspan=\"3\">paused</td>\n"+
+		"    </tr>\n"+
+		"  </tbody>\n"+
+		"</table>\n", buf.String())
+
+	// Groups titling single columns, one cut down by column selection.
+	buf.Reset()
+	opts := []fmter.Option{fmter.WithColumns("Service", "p95"), fmter.WithHeaderGroups(fmter.HeaderGroup{Title: "<Svc>"}, fmter.HeaderGroup{Title: "Requests", Span: 3})}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.HTML, opts, items...))
+	assert.Equal(t, ""+
+		"<table>\n"+
+		"  <thead>\n"+
+		"    <tr>\n"+
+		"      <th scope=\"colgroup\">&lt;Svc&gt;</th>\n"+
+		"      <th scope=\"colgroup\">Requests</th>\n"+
+		"    </tr>\n"+
+		"    <tr>\n"+
+		"      <th>Service</th>\n"+
+		"      <th>p95</th>\n"+
+		"    </tr>\n"+
+		"  </thead>\n"+
+		"  <tbody>\n"+
+		"    <tr>\n"+
+		"      <td>web</td>\n"+
+		"      <td>40ms</td>\n"+
+		"    </tr>\n"+
+		"    <tr>\n"+
+		"      <td>batch</td>\n"+
+		"      <td></td>\n"+
+		"    </tr>\n"+
+		"  </tbody>\n"+
+		"</table>\n", buf.String())
+}
+
+func TestSpansFlattened(t *testing.T) {
+	t.Parallel()
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "batch", Note: "paused"},
+	}
+	tests := map[fmter.Format]string{
+		fmter.Markdown: "" +
+			"| Service | Requests p50 | Requests p95 | Requests p99 |\n" +
+			"| ------- | ------------ | ------------ | ------------ |\n" +
+			"| web     | 12ms         | 40ms         | 90ms         |\n" +
+			"| batch   | paused       |              |              |\n",
+		fmter.CSV: "Service,Requests p50,Requests p95,Requests p99\nweb,12ms,40ms,90ms\nbatch,paused,,\n",
+	}
+	for f, want := range tests {
+		var buf bytes.Buffer
+		require.NoError(t, fmter.Write(&buf, f, items...))
+		assert.Equal(t, want, buf.String(), f)
+
+		buf.Reset()
+		require.NoError(t, fmter.WriteIter(&buf, f, slices.Values(items)))
+		assert.Equal(t, want, buf.String(), f)
+	}
+}
+
+func TestSpansPartialGroups(t *testing.T) {
+	t.Parallel()
+	// Columns past the last group are ungrouped.
+	var buf bytes.Buffer
+	opts := []fmter.Option{fmter.WithHeaderGroups(fmter.HeaderGroup{}, fmter.HeaderGroup{Title: "Requests"})}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.CSV, opts, latencyRow{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"}))
+	assert.Equal(t, "Service,Requests p50,p95,p99\nweb,12ms,40ms,90ms\n", buf.String())
+}
+
+func TestSpansWriteErrors(t *testing.T) {
+	t.Parallel()
+	items := []latencyRow{
+		{Service: "web", P50: "12ms", P95: "40ms", P99: "90ms"},
+		{Service: "batch", Note: "paused"},
+		{Service: "api", P50: "8ms", P95: "21ms", P99: "55ms"},
+	}
+	lines := borderLight.Chars()
+	lines.RowLines = true
+	for _, opts := range [][]fmter.Option{
+		{fmter.WithTitle("Latency")},
+		{fmter.WithBorderSet(lines)},
+		{fmter.WithBorder(fmter.BorderNone)},
+	} {
+		for n := range 30 {
+			err := fmter.WriteWith(&failAfterN{n: n}, fmter.Table, opts, items...)
+			if err == nil {
+				break
+			}
+			require.ErrorIs(t, err, errWriteFailed, "after %d writes", n)
+		}
+	}
+	for n := range 30 {
+		err := fmter.Write(&failAfterN{n: n}, fmter.HTML, items...)
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, errWriteFailed, "after %d writes", n)
+	}
+}
+
+// --- Markdown tables ---
+
+type remarkRow struct {
+	Name, Note string
+}
+
+func (remarkRow) Header() []string  { return []string{"Name", "Note"} }
+func (r remarkRow) Row() []string   { return []string{r.Name, r.Note} }
+func (remarkRow) WrapWidths() []int { return []int{0, 12} }
+
+func TestWriteMarkdownEscaping(t *testing.T) {
+	t.Parallel()
+	items := []remarkRow{
+		{Name: "a|b", Note: "line one\nline two"},
+		{Name: "*not bold*", Note: "wrapped at twelve columns"},
+		{Name: `C:\tmp`, Note: "<b>[x]</b> `code`"},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Markdown, items...))
+	assert.Equal(t, ""+
+		"| Name         | Note                            |\n"+
+		"| ------------ | ------------------------------- |\n"+
+		"| a\\|b         | line one<br>line two            |\n"+
+		"| \\*not bold\\* | wrapped at<br>twelve<br>columns |\n"+
+		"| C:\\\\tmp      | \\<b\\>\\[x\\]\\</b\\><br>\\`code\\`    |\n", buf.String())
+}
+
+type countedRemark struct{ remarkRow }
+
+func (countedRemark) NumberHeader() string { return "#" }
+
+func (countedRemark) Aggregates() []fmter.Aggregate {
+	return []fmter.Aggregate{fmter.AggregateCount}
+}
+
+func TestWriteMarkdownNumberedWrapped(t *testing.T) {
+	t.Parallel()
+	items := []countedRemark{{remarkRow{Name: "api", Note: "wrapped at twelve columns"}}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Markdown, items...))
+	assert.Equal(t, ""+
+		"|   # | Name  | Note                            |\n"+
+		"| --: | ----- | ------------------------------- |\n"+
+		"|   1 | api   | wrapped at<br>twelve<br>columns |\n"+
+		"|     | **1** |                                 |\n", buf.String())
+}
+
+func TestWriteMarkdownTitleFooterCaption(t *testing.T) {
+	t.Parallel()
+	items := []richRow{{Name: "Alice", Age: "30", Status: "active"}, {Name: "Bob", Age: "4", Status: "idle"}}
+	var buf bytes.Buffer
+	require.NoError(t, fmter.Write(&buf, fmter.Markdown, items...))
+	assert.Equal(t, ""+
+		"## People\n"+
+		"\n"+
+		"|   # | Name      |   Age | Status |\n"+
+		"| --: | --------- | ----: | :----: |\n"+
+		"|   1 | Alice     |    30 | active |\n"+
+		"|   2 | Bob       |     4 |  idle  |\n"+
+		"|     | **Total** | **2** |        |\n"+
+		"\n"+
+		"2 results\n", buf.String())
+
+	// Numbered groups keep their labels out of the number column.
+	buf.Reset()
+	zones := []numberedZoneRow{{zoneRow{"web", "a", "10"}}, {zoneRow{"api", "a", "20"}}, {zoneRow{"db", "b", "5"}}}
+	opts := []fmter.Option{fmter.WithTitle("Zones"), fmter.WithCaption("3 hosts")}
+	require.NoError(t, fmter.WriteWith(&buf, fmter.Markdown, opts, zones...))
+	assert.Equal(t, ""+
+		"## Zones\n"+
+		"\n"+
+		"|   # | Name       | CPU    |\n"+
+		"| --: | ---------- | ------ |\n"+
+		"|     | **Zone a** |        |\n"+
+		"|   1 | web        | 10     |\n"+
+		"|   2 | api        | 20     |\n"+
+		"|     |            | **30** |\n"+
+		"|     | **Zone b** |        |\n"+
+		"|   3 | db         | 5      |\n"+
+		"|     |            | **5**  |\n"+
+		"\n"+
+		"3 hosts\n", buf.String())
+}
+
+func TestWriteMarkdownTitleCaptionErrors(t *testing.T) {
+	t.Parallel()
+	items := []richRow{{Name: "Alice", Age: "30", Status: "active"}}
+	for n := range 10 {
+		err := fmter.Write(&failAfterN{n: n}, fmter.Markdown, items...)
+		if err == nil {
+			break
+		}
+		require.ErrorIs(t, err, errWriteFailed, "after %d writes", n)
+	}
+}
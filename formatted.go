@@ -0,0 +1,178 @@
+package fmter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// formatItem returns the output of v's [Formatter] for f, or nil when v does
+// not implement it or falls through to default rendering.
+func formatItem(f Format, v any) ([]byte, error) {
+	fmtr, ok := v.(Formatter)
+	if !ok {
+		return nil, nil
+	}
+	return fmtr.Format(f)
+}
+
+// writeFormatted renders items that include [Formatter] implementations,
+// keeping each item's output in its input position. Built-in formats embed
+// the Formatter output in place of the item: as a raw element in JSON and
+// YAML, as a row in the tabular formats, and verbatim elsewhere. Other
+// formats render the runs of default items between Formatter outputs.
+func writeFormatted[T any](w io.Writer, f Format, items []T, o *options) error {
+	formatted := make([][]byte, len(items))
+	found := false
+	for i, item := range items {
+		data, err := formatItem(f, item)
+		if err != nil {
+			return err
+		}
+		formatted[i] = data
+		found = found || data != nil
+	}
+	if !found {
+		return render(w, f, items, o)
+	}
+	if !slices.Contains(formats, f) {
+		return writeRuns(w, f, items, formatted, o)
+	}
+	co := *o
+	co.formatted = formatted
+	if isTabular(f) {
+		co.formattedRows = make([][]string, len(items))
+		for i, data := range formatted {
+			if data == nil {
+				continue
+			}
+			row, err := parseRow(f, data, o.delimiterFor(items[0]), i)
+			if err != nil {
+				return err
+			}
+			co.formattedRows[i] = row
+		}
+	}
+	return render(w, f, items, &co)
+}
+
+// writeRuns writes each Formatter output verbatim and renders the items
+// between them as separate batches.
+func writeRuns[T any](w io.Writer, f Format, items []T, formatted [][]byte, o *options) error {
+	start := 0
+	flush := func(end int) error {
+		if start == end {
+			return nil
+		}
+		return render(w, f, items[start:end], o)
+	}
+	for i, data := range formatted {
+		if data == nil {
+			continue
+		}
+		if err := flush(i); err != nil {
+			return err
+		}
+		start = i + 1
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return flush(len(items))
+}
+
+// parseRow reads Formatter output as a pre-rendered row: one CSV record using
+// comma as the delimiter for CSV, and tab-separated cells for the other
+// tabular formats.
+func parseRow(f Format, data []byte, comma rune, index int) ([]string, error) {
+	if f != CSV {
+		return splitRow(data), nil
+	}
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: Formatter output for item %d: %w", ErrInvalidFormat, index, err)
+	}
+	if len(records) != 1 {
+		return nil, fmt.Errorf("%w: Formatter output for item %d must be one CSV record, got %d", ErrInvalidFormat, index, len(records))
+	}
+	return records[0], nil
+}
+
+// splitRow splits Formatter output into tab-separated cells, ignoring a
+// trailing newline.
+func splitRow(data []byte) []string {
+	line := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	return strings.Split(line, "\t")
+}
+
+// jsonElement returns Formatter output as a raw JSON array element.
+func jsonElement(data []byte, index int) (json.RawMessage, error) {
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%w: Formatter output for item %d is not valid JSON", ErrInvalidFormat, index)
+	}
+	return json.RawMessage(data), nil
+}
+
+// yamlElement returns Formatter output as a YAML node to embed in the
+// sequence.
+func yamlElement(data []byte, index int) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: Formatter output for item %d: %w", ErrInvalidFormat, index, err)
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}, nil
+	}
+	return doc.Content[0], nil
+}
+
+// elements returns items with each Formatter output replaced by the element
+// convert builds from it, and each [Parent] by the element nest builds.
+func elements[T, E any](items []T, o *options, convert func([]byte, int) (E, error), nest func(any) (E, error)) ([]any, error) {
+	elems := make([]any, len(items))
+	for i, item := range items {
+		data, ok := o.formattedAt(i)
+		if !ok && !o.nests(item) {
+			elems[i] = item
+			continue
+		}
+		var elem E
+		var err error
+		if ok {
+			elem, err = convert(data, i)
+		} else {
+			elem, err = nest(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+		elems[i] = elem
+	}
+	return elems, nil
+}
+
+// formattedAt returns the Formatter output of the item at index i, if any.
+func (o *options) formattedAt(i int) ([]byte, bool) {
+	if i < len(o.formatted) && o.formatted[i] != nil {
+		return o.formatted[i], true
+	}
+	return nil, false
+}
+
+// rowAt returns the row of the item v at index i, taken from its Formatter
+// output when it has one.
+func (o *options) rowAt(i int, v any) []string {
+	if i < len(o.formattedRows) && o.formattedRows[i] != nil {
+		return o.formattedRows[i]
+	}
+	return o.rowOf(v)
+}
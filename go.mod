@@ -3,13 +3,14 @@ module github.com/bjaus/fmter
 go 1.25.7
 
 require (
+	github.com/mattn/go-runewidth v0.0.19
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/sys v0.47.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/clipperhouse/uax29/v2 v2.2.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
-	github.com/mattn/go-runewidth v0.0.19 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 )
@@ -0,0 +1,85 @@
+package fmter
+
+// WithGroupHeaders turns the group header rows of [Grouped] items on or off,
+// overriding [GroupHeaded]. Without GroupHeaded, a group's header is its key.
+func WithGroupHeaders(enabled bool) Option {
+	return func(o *options) { o.groupHeaders = &enabled }
+}
+
+// WithSubtotals overrides [Subtotaled]. Aggregates are positional, one per
+// column, like [WithAggregates].
+func WithSubtotals(aggs ...Aggregate) Option {
+	return func(o *options) { o.subtotals = aggs }
+}
+
+// groupHeaderFor returns the function naming each group of v's type in a
+// group header row, or nil when group headers are off.
+func (o *options) groupHeaderFor(v any) func(group string) string {
+	if _, ok := v.(Grouped); !ok || (o.groupHeaders != nil && !*o.groupHeaders) {
+		return nil
+	}
+	if g, ok := v.(GroupHeaded); ok {
+		return g.GroupHeader
+	}
+	if o.groupHeaders != nil {
+		return func(group string) string { return group }
+	}
+	return nil
+}
+
+func (o *options) subtotalsFor(v any) []Aggregate {
+	if _, ok := v.(Grouped); !ok {
+		return nil
+	}
+	if o.subtotals != nil {
+		return o.subtotals
+	}
+	if o.subtotalSource != nil {
+		return o.subtotalSource
+	}
+	if o.rowSource != nil {
+		return nil
+	}
+	if s, ok := v.(Subtotaled); ok {
+		return s.Subtotals()
+	}
+	return nil
+}
+
+// groupOf returns the group key of v, or "" when v is not [Grouped].
+func groupOf(v any) string {
+	if g, ok := v.(Grouped); ok {
+		return g.Group()
+	}
+	return ""
+}
+
+// groupRuns splits items into runs of consecutive items with the same group
+// key, returned as the end index of each run.
+func groupRuns[T any](items []T) []int {
+	var ends []int
+	for i := range items {
+		if i > 0 && groupOf(any(items[i])) != groupOf(any(items[i-1])) {
+			ends = append(ends, i)
+		}
+	}
+	return append(ends, len(items))
+}
+
+// subtotalRows returns the subtotal row of each group run in rows.
+func subtotalRows(aggs []Aggregate, rows [][]string, ends []int) [][]string {
+	if newAggregator(aggs) == nil {
+		return nil
+	}
+	var subtotals [][]string
+	start := 0
+	for _, end := range ends {
+		agg := newAggregator(aggs)
+		for _, row := range rows[start:end] {
+			agg.add(row)
+		}
+		subtotals = append(subtotals, agg.footer(nil))
+		start = end
+	}
+	return subtotals
+}
@@ -4,40 +4,45 @@ import (
 	"fmt"
 	"html"
 	"io"
+	"strings"
 )
 
-func writeHTML[T any](w io.Writer, items []T) error {
+func writeHTML[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
-	first := any(items[0])
-	if _, ok := first.(Rower); !ok {
-		return fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, HTML, items[0])
+	if err := checkItems(HTML, items, o); err != nil {
+		return err
 	}
+	first := any(items[0])
 
-	var aligns []Alignment
-	if a, ok := first.(Aligned); ok {
-		aligns = a.Alignments()
+	cols, err := o.columnsFor(first)
+	if err != nil {
+		return err
 	}
+	aligns := pick(o.alignsFor(first), cols)
 
 	if _, err := fmt.Fprintln(w, "<table>"); err != nil {
 		return err
 	}
 
-	if t, ok := first.(Titled); ok {
-		title := t.Title()
-		if title != "" {
-			if _, err := fmt.Fprintf(w, "  <caption>%s</caption>\n", html.EscapeString(title)); err != nil {
-				return err
-			}
+	if title := o.titleFor(first); title != "" {
+		if _, err := fmt.Fprintf(w, "  <caption>%s</caption>\n", html.EscapeString(title)); err != nil {
+			return err
 		}
 	}
 
-	if h, ok := first.(Headed); ok {
-		header := h.Header()
+	column := func(i int) int { return itemColumn(cols, i) }
+	if header := o.headerFor(first); header != nil {
+		header = pick(header, cols)
 		if _, err := fmt.Fprintln(w, "  <thead>"); err != nil {
 			return err
 		}
+		if groups := o.headerGroupsFor(first); groups != nil {
+			if err := writeHTMLGroups(w, groups, len(header), column); err != nil {
+				return err
+			}
+		}
 		if _, err := fmt.Fprintln(w, "    <tr>"); err != nil {
 			return err
 		}
@@ -55,16 +60,45 @@ func writeHTML[T any](w io.Writer, items []T) error {
 		}
 	}
 
-	if _, err := fmt.Fprintln(w, "  <tbody>"); err != nil {
-		return err
-	}
-	for _, item := range items {
-		row := any(item).(Rower).Row()
-		if _, err := fmt.Fprintln(w, "    <tr>"); err != nil {
+	_, grouped := first.(Grouped)
+	groupHeader := o.groupHeaderFor(first)
+	subtotals := pick(o.subtotalsFor(first), cols)
+	agg := newAggregator(pick(o.aggregatesFor(first), cols))
+	var sub *aggregator
+	for i, item := range items {
+		row := pick(o.rowAt(i, item), cols)
+		if group := groupOf(any(item)); i == 0 || (grouped && group != groupOf(any(items[i-1]))) {
+			// Each group is a <tbody> of its own.
+			if i > 0 {
+				if err := endHTMLGroup(w, sub, aligns); err != nil {
+					return err
+				}
+			}
+			if err := beginHTMLGroup(w, groupHeader, group, len(row)); err != nil {
+				return err
+			}
+			sub = newAggregator(subtotals)
+		}
+		agg.add(row)
+		sub.add(row)
+		spans := rowSpans(any(item), len(row), column)
+		cs, cellStyled := any(item).(CellStyled)
+		rowClass := ""
+		if rs, ok := any(item).(RowStyled); ok {
+			rowClass = rs.RowStyle().Class
+		}
+		if _, err := fmt.Fprintf(w, "    <tr%s>\n", classAttr(rowClass)); err != nil {
 			return err
 		}
-		for i, cell := range row {
-			style := alignStyle(aligns, i)
+		for j, cell := range row {
+			colspan, drawn := colspanAttr(spans, j)
+			if !drawn {
+				continue
+			}
+			style := colspan + alignStyle(aligns, j)
+			if cellStyled {
+				style = classAttr(cs.CellStyle(column(j), cell).Class) + style
+			}
 			if _, err := fmt.Fprintf(w, "      <td%s>%s</td>\n", style, html.EscapeString(cell)); err != nil {
 				return err
 			}
@@ -73,12 +107,12 @@ func writeHTML[T any](w io.Writer, items []T) error {
 			return err
 		}
 	}
-	if _, err := fmt.Fprintln(w, "  </tbody>"); err != nil {
+	if err := endHTMLGroup(w, sub, aligns); err != nil {
 		return err
 	}
 
-	if f, ok := first.(Footered); ok {
-		footer := f.Footer()
+	if _, ok := first.(Footered); ok || agg != nil {
+		footer := agg.footer(footerOf(first, cols))
 		if _, err := fmt.Fprintln(w, "  <tfoot>"); err != nil {
 			return err
 		}
@@ -99,7 +133,74 @@ func writeHTML[T any](w io.Writer, items []T) error {
 		}
 	}
 
-	_, err := fmt.Fprintln(w, "</table>")
+	_, err = fmt.Fprintln(w, "</table>")
+	return err
+}
+
+// writeHTMLGroups writes the header row of groups over n columns, each
+// group's title spanning its columns.
+func writeHTMLGroups(w io.Writer, groups []HeaderGroup, n int, column func(int) int) error {
+	cells, spans := groupRow(groups, n, column)
+	var sb strings.Builder
+	sb.WriteString("    <tr>\n")
+	for i, cell := range cells {
+		colspan, drawn := colspanAttr(spans, i)
+		if !drawn {
+			continue
+		}
+		if cell == "" {
+			sb.WriteString("      <th" + colspan + "></th>\n")
+			continue
+		}
+		fmt.Fprintf(&sb, "      <th%s scope=\"colgroup\">%s</th>\n", colspan, html.EscapeString(cell))
+	}
+	sb.WriteString("    </tr>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// colspanAttr returns the colspan attribute of cell i of a row laid out by
+// spans, and false when another cell covers it.
+func colspanAttr(spans []int, i int) (string, bool) {
+	if spans == nil || spans[i] == 1 {
+		return "", true
+	}
+	if spans[i] == 0 {
+		return "", false
+	}
+	return fmt.Sprintf(` colspan="%d"`, spans[i]), true
+}
+
+// beginHTMLGroup opens a <tbody>, starting with a header row naming group
+// that spans all columns when groupHeader is set.
+func beginHTMLGroup(w io.Writer, groupHeader func(string) string, group string, span int) error {
+	if _, err := fmt.Fprintln(w, "  <tbody>"); err != nil {
+		return err
+	}
+	if groupHeader == nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "    <tr>\n      <th colspan=\"%d\" scope=\"rowgroup\">%s</th>\n    </tr>\n", span, html.EscapeString(groupHeader(group)))
+	return err
+}
+
+// endHTMLGroup closes a <tbody>, ending it with the subtotal row of sub when
+// there is one.
+func endHTMLGroup(w io.Writer, sub *aggregator, aligns []Alignment) error {
+	if sub != nil {
+		if _, err := fmt.Fprintln(w, `    <tr class="subtotal">`); err != nil {
+			return err
+		}
+		for i, cell := range sub.footer(nil) {
+			if _, err := fmt.Fprintf(w, "      <td%s>%s</td>\n", alignStyle(aligns, i), html.EscapeString(cell)); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, "    </tr>"); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w, "  </tbody>")
 	return err
 }
 
@@ -116,4 +217,3 @@ func alignStyle(aligns []Alignment, col int) string {
 		return ""
 	}
 }
-
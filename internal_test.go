@@ -3,10 +3,12 @@ package fmter
 import (
 	"bytes"
 	"errors"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var errInternalWrite = errors.New("write failed")
@@ -38,6 +40,147 @@ func TestWrapCellBasic(t *testing.T) {
 	assert.Equal(t, []string{"Hel", "lo"}, lines)
 }
 
+func TestWrapCellWords(t *testing.T) {
+	t.Parallel()
+	// Lines break at spaces; words wider than the width are hard-broken and
+	// the rest of the word continues on the next line.
+	assert.Equal(t, []string{"the quick", "brown fox"}, wrapCell("the quick brown fox", 9))
+	assert.Equal(t, []string{"a", "superca", "lifrag", "ok"}, wrapCell("a supercalifrag ok", 7))
+	assert.Equal(t, []string{"a", "superca", "lif ok"}, wrapCell("a supercalif ok", 7))
+	assert.Equal(t, []string{""}, wrapCell("   ", 2))
+}
+
+func TestWrapCellNewlines(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, []string{"one", "two", ""}, wrapCell("one\r\ntwo\n", 0))
+	assert.Equal(t, []string{"a b", "c", "d"}, wrapCell("a b c\nd", 3))
+}
+
+func TestWrapCellKeepsSpaces(t *testing.T) {
+	t.Parallel()
+	// Indentation and runs of spaces survive wrapping; only the spaces at a
+	// break are dropped.
+	cell := "func main() {\n    fmt.Println(\"hi\")  // greet the world\n}"
+	assert.Equal(t, []string{
+		"func main() {",
+		`    fmt.Println("hi")`,
+		"// greet the world",
+		"}",
+	}, wrapCell(cell, 22))
+	assert.Equal(t, []string{"a  b", "c"}, wrapCell("a  b   c", 5))
+}
+
+func TestCellWidth(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 5, cellWidth("hello"))
+	assert.Equal(t, 6, cellWidth("ab\nabcdef\r\nabc"))
+	assert.Equal(t, 4, cellWidth("你好\nab"))
+}
+
+func TestEscapeLen(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 5, escapeLen("\x1b[31mred"))
+	assert.Equal(t, 4, escapeLen("\x1b[31"))
+	assert.Equal(t, 7, escapeLen("\x1b]0;hi\ax"))
+	assert.Equal(t, 8, escapeLen("\x1b]0;hi\x1b\\x"))
+	assert.Equal(t, 6, escapeLen("\x1b]0;hi"))
+	assert.Equal(t, 2, escapeLen("\x1bMx"))
+	assert.Equal(t, 0, escapeLen("\x1b"))
+	assert.Equal(t, 0, escapeLen("x"))
+}
+
+func TestDisplayWidthANSI(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, 3, displayWidth("\x1b[1;31mred\x1b[0m"))
+	assert.Equal(t, 4, displayWidth("\x1b]8;;https://x.io\x1b\\link\x1b]8;;\x1b\\"))
+	assert.Equal(t, 4, displayWidth("你好"))
+}
+
+func TestCutWidth(t *testing.T) {
+	t.Parallel()
+	head, rest := cutWidth("ab\x1b[31mcd\x1b[0m", 2)
+	assert.Equal(t, "ab", head)
+	assert.Equal(t, "\x1b[31mcd\x1b[0m", rest)
+
+	head, rest = cutWidth("\x1b[31mabcd\x1b[0m", 3)
+	assert.Equal(t, "\x1b[31mabc", head)
+	assert.Equal(t, "d\x1b[0m", rest)
+
+	head, rest = cutWidth("\x1b[31m你", 1)
+	assert.Empty(t, head)
+	assert.Equal(t, "\x1b[31m你", rest)
+
+	head, rest = cutWidth("ab", 5)
+	assert.Equal(t, "ab", head)
+	assert.Empty(t, rest)
+}
+
+func TestTruncateWidth(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "\x1b[31mhi\x1b[0m", truncateWidth("\x1b[31mhi\x1b[0m", 2, "..."))
+	assert.Equal(t, "\x1b[31mhel...\x1b[0m", truncateWidth("\x1b[31mhello world\x1b[0m", 6, "..."))
+	assert.Equal(t, "\x1b[31mhe\x1b[0mll", truncateWidth("\x1b[31mhe\x1b[0mllo", 4, ""))
+	assert.Equal(t, "hello...", truncateWidth("hello world", 8, "..."))
+	link := "\x1b]8;;https://x.io\a"
+	assert.Equal(t, link+"li"+linkClose, truncateWidth(link+"link\x1b]8;;\a", 2, ""))
+}
+
+func TestWrapCellANSI(t *testing.T) {
+	t.Parallel()
+	// A style spanning a break is closed at the end of the line and
+	// reopened on the next, whether the break comes from wrapping or from
+	// an embedded newline.
+	red := "\x1b[31m"
+	assert.Equal(t, []string{red + "one" + sgrReset, red + "two" + sgrReset}, wrapCell(red+"one two"+sgrReset, 4))
+	assert.Equal(t, []string{red + "ab" + sgrReset, red + "cd" + sgrReset}, wrapCell(red+"abcd"+sgrReset, 2))
+	assert.Equal(t, []string{red + "a" + sgrReset, red + "b" + sgrReset}, wrapCell(red+"a\nb", 0))
+	assert.Equal(t, []string{red + "你" + sgrReset, red + "好" + sgrReset}, wrapCell(red+"你好", 1))
+	// A zero-width word is kept rather than dropped.
+	assert.Equal(t, []string{red + " one" + sgrReset, red + "two" + sgrReset}, wrapCell(red+" one two", 4))
+}
+
+func TestANSIState(t *testing.T) {
+	t.Parallel()
+	var st ansiState
+	st.scan("\x1b[1m\x1b]8;id=1;https://x.io\x1b\\a\x1b[2K")
+	assert.Equal(t, "\x1b[1m\x1b]8;id=1;https://x.io\x1b\\", st.open())
+	assert.Equal(t, sgrReset+linkClose, st.close())
+	st.scan("b\x1b[m\x1b]8;;\x1b\\")
+	assert.Empty(t, st.open())
+	assert.Empty(t, st.close())
+}
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, newAggregator([]Aggregate{AggregateNone}))
+
+	a := newAggregator([]Aggregate{AggregateSum, AggregateAvg, AggregateMin, AggregateSum, AggregateMax})
+	for _, row := range [][]string{
+		{"1,000", "1.5", "", "1.5GB", "x"},
+		{"250", "3h", "10s", "500MB"},
+		{"n/a", "2", "2m", "1 KB"},
+	} {
+		a.add(row)
+	}
+	// Cells that don't parse, or parse as a different kind than the first,
+	// are left out of numeric aggregates.
+	assert.Equal(t, []string{"1250", "1.75", "10s", "2 GB", "", "note"}, a.footer([]string{"", "", "", "", "", "note"}))
+
+	a = newAggregator([]Aggregate{AggregateAvg})
+	a.add([]string{"90s"})
+	a.add([]string{"1m"})
+	assert.Equal(t, []string{"1m15s"}, a.footer(nil))
+
+	a = newAggregator([]Aggregate{AggregateAvg, AggregateAvg})
+	for _, row := range [][]string{{"10ms", "1s"}, {"30ms", "2s"}, {"1s", "2s"}} {
+		a.add(row)
+	}
+	assert.Equal(t, []string{"346.667ms", "1.667s"}, a.footer(nil))
+
+	assert.Equal(t, "-1.5 KB", formatBytes(-1500, false))
+	assert.Equal(t, "1024 PiB", formatBytes(1<<60, true))
+}
+
 func TestExtendStylesNoop(t *testing.T) {
 	t.Parallel()
 	fn := func(s string) string { return s }
@@ -48,7 +191,7 @@ func TestExtendStylesNoop(t *testing.T) {
 func TestWriteCSVRowSuccess(t *testing.T) {
 	t.Parallel()
 	var buf bytes.Buffer
-	err := writeCSVRow(&buf, []string{"a", "b"})
+	err := writeCSVRow(&buf, []string{"a", "b"}, ',')
 	assert.NoError(t, err)
 	assert.Equal(t, "a,b\n", buf.String())
 }
@@ -57,7 +200,7 @@ func TestWriteCSVRowError(t *testing.T) {
 	t.Parallel()
 	w := &errWriterInternal{}
 	// Small data: flush error hit via cw.Error().
-	err := writeCSVRow(w, []string{"a", "b"})
+	err := writeCSVRow(w, []string{"a", "b"}, ',')
 	assert.Error(t, err)
 }
 
@@ -66,7 +209,7 @@ func TestWriteCSVRowLargeDataError(t *testing.T) {
 	w := &errWriterInternal{}
 	// Large data exceeds bufio buffer (4096 bytes), causing cw.Write to fail.
 	big := strings.Repeat("x", 5000)
-	err := writeCSVRow(w, []string{big})
+	err := writeCSVRow(w, []string{big}, ',')
 	assert.Error(t, err)
 }
 
@@ -75,3 +218,85 @@ type errWriterInternal struct{}
 func (e *errWriterInternal) Write([]byte) (int, error) {
 	return 0, errInternalWrite
 }
+
+func TestRowOfNonRower(t *testing.T) {
+	t.Parallel()
+	assert.Nil(t, rowOf("not a rower"))
+}
+
+func TestParseQuantity(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		in   string
+		want quantity
+		ok   bool
+	}{
+		"empty":        {in: "  ", ok: false},
+		"number":       {in: "1,234.5", want: quantity{value: 1234.5, kind: quantityNumber}, ok: true},
+		"percent":      {in: "50%", want: quantity{value: 50, kind: quantityNumber}, ok: true},
+		"duration":     {in: "1m30s", want: quantity{value: 90e9, kind: quantityDuration}, ok: true},
+		"binary size":  {in: "1.5 GiB", want: quantity{value: 1.5 * (1 << 30), kind: quantityBytes}, ok: true},
+		"decimal size": {in: "12kB", want: quantity{value: 12e3, kind: quantityBytes}, ok: true},
+		"kube size":    {in: "512Mi", want: quantity{value: 512 << 20, kind: quantityBytes}, ok: true},
+		"unknown unit": {in: "12 apples", ok: false},
+		"no digits":    {in: "GiB", ok: false},
+		"text":         {in: "Inf", ok: false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseQuantity(tt.in)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTerminalWidth(t *testing.T) {
+	t.Parallel()
+	assert.Zero(t, terminalWidth(&bytes.Buffer{}))
+
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Zero(t, terminalWidth(f))
+	assert.Zero(t, ttyWidth(f.Fd(), "120"))
+}
+
+func TestTTYWidth(t *testing.T) {
+	t.Parallel()
+	tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminal available:", err)
+	}
+	defer tty.Close()
+	assert.Equal(t, 120, ttyWidth(tty.Fd(), "120"))
+	size, _ := ttyColumns(tty.Fd())
+	assert.Equal(t, size, ttyWidth(tty.Fd(), "wide"))
+	assert.Equal(t, size, ttyWidth(tty.Fd(), "0"))
+}
+
+func TestColorEnabled(t *testing.T) {
+	t.Parallel()
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+	assert.True(t, colorEnabled(true, env(nil)))
+	assert.False(t, colorEnabled(false, env(nil)))
+	assert.False(t, colorEnabled(true, env(map[string]string{"NO_COLOR": "1"})))
+	assert.False(t, colorEnabled(true, env(map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"})))
+	assert.True(t, colorEnabled(false, env(map[string]string{"FORCE_COLOR": "1"})))
+	assert.False(t, colorEnabled(true, env(map[string]string{"FORCE_COLOR": "0"})))
+	assert.False(t, colorEnabled(true, env(map[string]string{"FORCE_COLOR": "false"})))
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+	assert.False(t, isTerminal(&bytes.Buffer{}))
+	tty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skip("no pseudo-terminal available:", err)
+	}
+	defer tty.Close()
+	assert.True(t, isTerminal(tty))
+}
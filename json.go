@@ -3,15 +3,27 @@ package fmter
 import (
 	"encoding/json"
 	"io"
+	"slices"
 )
 
-func writeJSON[T any](w io.Writer, items []T) error {
+func writeJSON[T any](w io.Writer, items []T, o *options) error {
 	enc := json.NewEncoder(w)
 	if len(items) > 0 {
-		if ind, ok := any(items[0]).(Indented); ok {
-			enc.SetIndent("", ind.Indent())
+		if indent, ok := o.indentFor(items[0]); ok {
+			enc.SetIndent("", indent)
 		}
 	}
+	if o.formatted != nil || (o.tree && slices.ContainsFunc(items, isParent[T])) {
+		elems, err := elements(items, o, jsonElement, jsonTree)
+		if err != nil {
+			return err
+		}
+		return encodeJSON(enc, elems)
+	}
+	return encodeJSON(enc, items)
+}
+
+func encodeJSON[T any](enc *json.Encoder, items []T) error {
 	if len(items) == 1 {
 		return enc.Encode(items[0])
 	}
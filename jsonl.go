@@ -5,15 +5,34 @@ import (
 	"io"
 )
 
-func writeJSONL[T any](w io.Writer, items []T) error {
-	for _, item := range items {
-		enc := json.NewEncoder(w)
-		if ind, ok := any(item).(Indented); ok {
-			enc.SetIndent("", ind.Indent())
+func writeJSONL[T any](w io.Writer, items []T, o *options) error {
+	for i, item := range items {
+		if data, ok := o.formattedAt(i); ok {
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			continue
 		}
-		if err := enc.Encode(item); err != nil {
+		if err := writeJSONLine(w, item, o); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// writeJSONLine writes item as one line of JSON, with its children nested
+// like JSON output when [WithTree] is set.
+func writeJSONLine(w io.Writer, item any, o *options) error {
+	var elem any = item
+	if o.nests(item) {
+		var err error
+		if elem, err = jsonTree(item); err != nil {
+			return err
+		}
+	}
+	enc := json.NewEncoder(w)
+	if indent, ok := o.indentFor(item); ok {
+		enc.SetIndent("", indent)
+	}
+	return enc.Encode(elem)
+}
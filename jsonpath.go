@@ -0,0 +1,744 @@
+package fmter
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const jsonPathPrefix = "jsonpath="
+
+// JSONPath returns a Format that renders items with a JSONPath template, in
+// the style of kubectl. Text outside braces is written verbatim; each {...}
+// action is evaluated against the JSON representation of the item:
+//
+//	fmter.JSONPath(`{.name}: {.spec.ports[*].port}`)
+//	fmter.JSONPath(`{range .ports[?(@.port > 1024)]}{.name}{"\t"}{end}`)
+//
+// Supported selectors are .key, ['key'], [n], [start:end:step], [*] and .*,
+// ..key (recursive descent), and [?(@.path op value)] filters with ==, !=,
+// <, <=, >, >=, or a bare path to test for existence. $ refers to the item
+// and @ to the current element inside {range}...{end}. An action yielding
+// several values writes them separated by spaces; missing values write
+// nothing. Each item is written on its own line, like [GoTemplate].
+//
+// Unlike kubectl, the template is evaluated against each item rather than a
+// List holding them all, so {.name} replaces {range .items[*]}{.name}{end}. A
+// template reading .items from an item without an items field fails with
+// [ErrInvalidTemplate] instead of writing empty lines.
+func JSONPath(tmpl string) Format {
+	return Format(jsonPathPrefix + tmpl)
+}
+
+type jsonPathKind int
+
+const (
+	jsonPathText jsonPathKind = iota
+	jsonPathExpr
+	jsonPathRange
+)
+
+// jsonPathNode is one piece of a parsed template: literal text, an action
+// whose paths are evaluated and joined, or a range over its first path.
+type jsonPathNode struct {
+	kind  jsonPathKind
+	text  string
+	paths []jsonPath
+	body  []jsonPathNode
+}
+
+type jsonPath struct {
+	root  bool // starts at $ rather than the current element
+	steps []jsonPathStep
+}
+
+type jsonPathStep struct {
+	recursive bool // applies to every descendant (..)
+	wildcard  bool
+	names     []string
+	indexes   []int
+	slice     *jsonPathSlice
+	filter    *jsonPathFilter
+}
+
+type jsonPathSlice struct {
+	start, end, step int
+	hasStart, hasEnd bool
+}
+
+type jsonPathFilter struct {
+	left, right jsonPathOperand
+	op          string // empty for an existence test
+}
+
+type jsonPathOperand struct {
+	path    jsonPath
+	isPath  bool
+	literal any
+}
+
+func writeJSONPath[T any](w io.Writer, tmpl string, items []T) error {
+	nodes, err := parseJSONPath(tmpl)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		if err := writeJSONPathItem(w, nodes, item, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func streamJSONPath[T any](w io.Writer, f Format, tmpl string, seq iter.Seq[T]) error {
+	nodes, err := parseJSONPath(tmpl)
+	if err != nil {
+		return err
+	}
+	var streamErr error
+	index := 0
+	seq(func(item T) bool {
+		i := index
+		index++
+		if ok, err := writeFormatter(w, f, item); ok || err != nil {
+			streamErr = err
+			return err == nil
+		}
+		if err := writeJSONPathItem(w, nodes, item, i); err != nil {
+			streamErr = err
+			return false
+		}
+		return true
+	})
+	return streamErr
+}
+
+// writeJSONPathItem writes the template evaluated against item, the item at
+// index i.
+func writeJSONPathItem(w io.Writer, nodes []jsonPathNode, item any, i int) error {
+	doc, err := jsonValue(item)
+	if err != nil {
+		return fmt.Errorf("item %d: %w", i, err)
+	}
+	fields, _ := doc.(map[string]any)
+	if _, ok := lookupKey(fields, "items"); !ok && readsItems(nodes) {
+		return fmt.Errorf("%w: jsonpath reads .items, which item %d lacks; templates apply to each item, not a list of them", ErrInvalidTemplate, i)
+	}
+	var b strings.Builder
+	execJSONPath(&b, nodes, doc, doc)
+	_, err = fmt.Fprintln(w, b.String())
+	return err
+}
+
+// readsItems reports whether a top-level action in nodes starts at .items,
+// the list field kubectl templates address. Templates here run against each
+// item rather than a List wrapping them, so such a path would silently find
+// nothing.
+func readsItems(nodes []jsonPathNode) bool {
+	for _, n := range nodes {
+		for _, p := range n.paths {
+			if len(p.steps) > 0 && !p.steps[0].recursive && slices.Contains(p.steps[0].names, "items") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func execJSONPath(b *strings.Builder, nodes []jsonPathNode, cur, root any) {
+	for _, n := range nodes {
+		switch n.kind {
+		case jsonPathText:
+			b.WriteString(n.text)
+		case jsonPathExpr:
+			var parts []string
+			for _, p := range n.paths {
+				for _, v := range p.eval(cur, root) {
+					parts = append(parts, jsonPathString(v))
+				}
+			}
+			b.WriteString(strings.Join(parts, " "))
+		case jsonPathRange:
+			results := n.paths[0].eval(cur, root)
+			if len(results) == 1 {
+				if arr, ok := results[0].([]any); ok {
+					results = arr
+				}
+			}
+			for _, v := range results {
+				execJSONPath(b, n.body, v, root)
+			}
+		}
+	}
+}
+
+func jsonPathString(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case map[string]any, []any:
+		data, _ := json.Marshal(val) // generic JSON values always encode
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// --- Evaluation ---
+
+func (p jsonPath) eval(cur, root any) []any {
+	nodes := []any{cur}
+	if p.root {
+		nodes = []any{root}
+	}
+	for _, step := range p.steps {
+		if step.recursive {
+			nodes = descendants(nodes)
+		}
+		var next []any
+		for _, n := range nodes {
+			next = append(next, step.apply(n, root)...)
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+func (s jsonPathStep) apply(v, root any) []any {
+	switch node := v.(type) {
+	case map[string]any:
+		if s.wildcard {
+			return childrenOf(node)
+		}
+		var out []any
+		for _, name := range s.names {
+			if val, ok := lookupKey(node, name); ok {
+				out = append(out, val)
+			}
+		}
+		return out
+	case []any:
+		switch {
+		case s.wildcard:
+			return node
+		case s.indexes != nil:
+			var out []any
+			for _, i := range s.indexes {
+				if i < 0 {
+					i += len(node)
+				}
+				if i >= 0 && i < len(node) {
+					out = append(out, node[i])
+				}
+			}
+			return out
+		case s.slice != nil:
+			return s.slice.apply(node)
+		case s.filter != nil:
+			var out []any
+			for _, e := range node {
+				if s.filter.match(e, root) {
+					out = append(out, e)
+				}
+			}
+			return out
+		}
+	}
+	return nil
+}
+
+func (s *jsonPathSlice) apply(arr []any) []any {
+	n := len(arr)
+	lo, hi := 0, n
+	if s.step < 0 {
+		lo, hi = -1, n-1
+	}
+	clamp := func(i int) int {
+		if i < 0 {
+			i += n
+		}
+		return min(max(i, lo), hi)
+	}
+	start, end := lo, hi
+	if s.step < 0 {
+		start, end = hi, lo
+	}
+	if s.hasStart {
+		start = clamp(s.start)
+	}
+	if s.hasEnd {
+		end = clamp(s.end)
+	}
+	var out []any
+	for i := start; (s.step > 0 && i < end) || (s.step < 0 && i > end); i += s.step {
+		out = append(out, arr[i])
+	}
+	return out
+}
+
+// descendants returns each node followed by all values nested within it.
+func descendants(nodes []any) []any {
+	var out []any
+	for _, n := range nodes {
+		out = append(out, n)
+		switch node := n.(type) {
+		case map[string]any:
+			out = append(out, descendants(childrenOf(node))...)
+		case []any:
+			out = append(out, descendants(node)...)
+		}
+	}
+	return out
+}
+
+// childrenOf returns the values of m ordered by key.
+func childrenOf(m map[string]any) []any {
+	out := make([]any, 0, len(m))
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		out = append(out, m[k])
+	}
+	return out
+}
+
+func (f *jsonPathFilter) match(v, root any) bool {
+	left, ok := f.left.value(v, root)
+	if f.op == "" || !ok {
+		return ok
+	}
+	right, ok := f.right.value(v, root)
+	if !ok {
+		return false
+	}
+	c, ok := compareJSON(left, right)
+	switch f.op {
+	case "==":
+		return ok && c == 0
+	case "!=":
+		return !ok || c != 0
+	case "<":
+		return ok && c < 0
+	case "<=":
+		return ok && c <= 0
+	case ">":
+		return ok && c > 0
+	default: // ">="
+		return ok && c >= 0
+	}
+}
+
+func (o jsonPathOperand) value(v, root any) (any, bool) {
+	if !o.isPath {
+		return o.literal, true
+	}
+	results := o.path.eval(v, root)
+	if len(results) == 0 {
+		return nil, false
+	}
+	return results[0], true
+}
+
+// compareJSON orders two scalar JSON values. Numbers and strings compare
+// within their type; bools and null only compare equal to themselves.
+func compareJSON(a, b any) (int, bool) {
+	if n, ok := a.(json.Number); ok {
+		a, _ = n.Float64()
+	}
+	if n, ok := b.(json.Number); ok {
+		b, _ = n.Float64()
+	}
+	switch av := a.(type) {
+	case float64:
+		if bv, ok := b.(float64); ok {
+			return cmp.Compare(av, bv), true
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case bool:
+		if bv, ok := b.(bool); ok && av == bv {
+			return 0, true
+		}
+	case nil:
+		if b == nil {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+// --- Parsing ---
+
+func parseJSONPath(tmpl string) ([]jsonPathNode, error) {
+	var actions []string
+	var texts []string
+	rest := tmpl
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			texts, actions = append(texts, rest), append(actions, "")
+			break
+		}
+		end := closingIndex(rest, open+1, '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: jsonpath %q has an unclosed '{'", ErrInvalidTemplate, tmpl)
+		}
+		texts, actions = append(texts, rest[:open]), append(actions, strings.TrimSpace(rest[open+1:end]))
+		rest = rest[end+1:]
+	}
+	p := &jsonPathParser{tmpl: tmpl, texts: texts, actions: actions}
+	return p.parse(false)
+}
+
+// jsonPathParser turns the template, split into text preceding each action,
+// into a node tree with {range} bodies nested.
+type jsonPathParser struct {
+	tmpl    string
+	texts   []string
+	actions []string
+	pos     int
+}
+
+func (p *jsonPathParser) parse(inRange bool) ([]jsonPathNode, error) {
+	var nodes []jsonPathNode
+	for ; p.pos < len(p.actions); p.pos++ {
+		if p.texts[p.pos] != "" {
+			nodes = append(nodes, jsonPathNode{kind: jsonPathText, text: p.texts[p.pos]})
+		}
+		action := p.actions[p.pos]
+		if action == "" {
+			continue
+		}
+		if action == "end" {
+			if !inRange {
+				return nil, fmt.Errorf("%w: jsonpath %q has {end} without {range}", ErrInvalidTemplate, p.tmpl)
+			}
+			return nodes, nil
+		}
+		if expr, ok := strings.CutPrefix(action, "range "); ok {
+			path, err := parseJSONPathExpr(expr)
+			if err != nil {
+				return nil, err
+			}
+			p.pos++
+			body, err := p.parse(true)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, jsonPathNode{kind: jsonPathRange, paths: []jsonPath{path}, body: body})
+			continue
+		}
+		node, err := parseJSONPathAction(action)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if inRange {
+		return nil, fmt.Errorf("%w: jsonpath %q has {range} without {end}", ErrInvalidTemplate, p.tmpl)
+	}
+	return nodes, nil
+}
+
+func parseJSONPathAction(action string) (jsonPathNode, error) {
+	if s, ok := unquoteJSONPath(action); ok {
+		return jsonPathNode{kind: jsonPathText, text: s}, nil
+	}
+	var node jsonPathNode
+	node.kind = jsonPathExpr
+	for _, expr := range splitTopLevel(action) {
+		path, err := parseJSONPathExpr(expr)
+		if err != nil {
+			return node, err
+		}
+		node.paths = append(node.paths, path)
+	}
+	return node, nil
+}
+
+func parseJSONPathExpr(expr string) (jsonPath, error) {
+	var p jsonPath
+	s := strings.TrimSpace(expr)
+	bad := func(msg string) (jsonPath, error) {
+		return jsonPath{}, fmt.Errorf("%w: jsonpath expression %q %s", ErrInvalidTemplate, expr, msg)
+	}
+	switch {
+	case strings.HasPrefix(s, "$"):
+		p.root = true
+		s = s[1:]
+	case strings.HasPrefix(s, "@"):
+		s = s[1:]
+	}
+	if s != "" && s[0] != '.' && s[0] != '[' {
+		return bad("must start with '.', '[', '$', or '@'")
+	}
+	for s != "" {
+		var step jsonPathStep
+		switch {
+		case strings.HasPrefix(s, ".."):
+			step.recursive = true
+			s = s[2:]
+			if s == "" || s[0] == '.' {
+				return bad("has an empty recursive descent")
+			}
+			if s[0] == '[' {
+				// ..[selector]: the bracket step picks up the descent.
+				p.steps = append(p.steps, step)
+				continue
+			}
+		case s[0] == '.':
+			s = s[1:]
+		case s[0] == '[':
+			end := closingIndex(s, 1, ']')
+			if end < 0 {
+				return bad("has an unclosed '['")
+			}
+			sel, err := parseJSONPathBracket(s[1:end])
+			if err != nil {
+				return bad(err.Error())
+			}
+			if n := len(p.steps); n > 0 && p.steps[n-1].recursive && p.steps[n-1].isEmpty() {
+				sel.recursive = true
+				p.steps = p.steps[:n-1]
+			}
+			p.steps = append(p.steps, sel)
+			s = s[end+1:]
+			continue
+		default:
+			return bad("is malformed")
+		}
+		end := strings.IndexAny(s, ".[")
+		if end < 0 {
+			end = len(s)
+		}
+		switch name := s[:end]; name {
+		case "":
+			// "." alone selects the current element.
+		case "*":
+			step.wildcard = true
+			p.steps = append(p.steps, step)
+		default:
+			step.names = []string{name}
+			p.steps = append(p.steps, step)
+		}
+		s = s[end:]
+	}
+	return p, nil
+}
+
+func (s jsonPathStep) isEmpty() bool {
+	return !s.wildcard && s.names == nil && s.indexes == nil && s.slice == nil && s.filter == nil
+}
+
+// parseJSONPathBracket parses the contents of a [...] selector. Errors are
+// returned unwrapped for the caller to annotate.
+func parseJSONPathBracket(sel string) (jsonPathStep, error) {
+	var step jsonPathStep
+	sel = strings.TrimSpace(sel)
+	switch {
+	case sel == "*":
+		step.wildcard = true
+	case strings.HasPrefix(sel, "?"):
+		inner, ok := strings.CutPrefix(strings.TrimSpace(sel[1:]), "(")
+		if !ok || !strings.HasSuffix(inner, ")") {
+			return step, fmt.Errorf("has a filter not wrapped in ?(...)")
+		}
+		f, err := parseJSONPathFilter(strings.TrimSuffix(inner, ")"))
+		if err != nil {
+			return step, err
+		}
+		step.filter = f
+	case strings.HasPrefix(sel, "'") || strings.HasPrefix(sel, `"`):
+		for _, part := range splitTopLevel(sel) {
+			name, ok := unquoteJSONPath(part)
+			if !ok {
+				return step, fmt.Errorf("has an invalid key %s", part)
+			}
+			step.names = append(step.names, name)
+		}
+	case strings.Contains(sel, ":"):
+		parts := strings.Split(sel, ":")
+		if len(parts) > 3 {
+			return step, fmt.Errorf("has an invalid slice [%s]", sel)
+		}
+		sl := &jsonPathSlice{step: 1}
+		bounds := []*int{&sl.start, &sl.end, &sl.step}
+		for i, part := range parts {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil || (i == 2 && n == 0) {
+				return step, fmt.Errorf("has an invalid slice [%s]", sel)
+			}
+			*bounds[i] = n
+		}
+		sl.hasStart = strings.TrimSpace(parts[0]) != ""
+		sl.hasEnd = len(parts) > 1 && strings.TrimSpace(parts[1]) != ""
+		step.slice = sl
+	default:
+		for part := range strings.SplitSeq(sel, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return step, fmt.Errorf("has an invalid index [%s]", sel)
+			}
+			step.indexes = append(step.indexes, n)
+		}
+	}
+	return step, nil
+}
+
+var jsonPathOperators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+func parseJSONPathFilter(expr string) (*jsonPathFilter, error) {
+	f := &jsonPathFilter{}
+	left, right := expr, ""
+	if i, op := findOperator(expr); i >= 0 {
+		f.op = op
+		left, right = expr[:i], expr[i+len(op):]
+	}
+	var err error
+	if f.left, err = parseJSONPathOperand(left); err != nil {
+		return nil, err
+	}
+	if f.op == "" {
+		if !f.left.isPath {
+			return nil, fmt.Errorf("has a filter without a path")
+		}
+		return f, nil
+	}
+	if f.right, err = parseJSONPathOperand(right); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// findOperator returns the position of the first comparison operator in s
+// outside quotes, or -1.
+func findOperator(s string) (int, string) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		default:
+			for _, op := range jsonPathOperators {
+				if strings.HasPrefix(s[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+func parseJSONPathOperand(s string) (jsonPathOperand, error) {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "@") || strings.HasPrefix(s, "$") {
+		path, err := parseJSONPathExpr(s)
+		if err != nil {
+			return jsonPathOperand{}, err
+		}
+		return jsonPathOperand{path: path, isPath: true}, nil
+	}
+	if str, ok := unquoteJSONPath(s); ok {
+		return jsonPathOperand{literal: str}, nil
+	}
+	switch s {
+	case "true":
+		return jsonPathOperand{literal: true}, nil
+	case "false":
+		return jsonPathOperand{literal: false}, nil
+	case "null":
+		return jsonPathOperand{}, nil
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return jsonPathOperand{literal: n}, nil
+	}
+	return jsonPathOperand{}, fmt.Errorf("has an invalid filter operand %q", s)
+}
+
+// unquoteJSONPath unquotes a double-quoted string with Go escapes or a
+// single-quoted string taken literally.
+func unquoteJSONPath(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return "", false
+	}
+	switch {
+	case s[0] == '"' && s[len(s)-1] == '"':
+		out, err := strconv.Unquote(s)
+		return out, err == nil
+	case s[0] == '\'' && s[len(s)-1] == '\'':
+		return s[1 : len(s)-1], true
+	}
+	return "", false
+}
+
+// splitTopLevel splits s on commas outside quotes, brackets, and parentheses.
+func splitTopLevel(s string) []string {
+	var parts []string
+	var quote byte
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// closingIndex returns the index of the first close byte in s at or after
+// from that is outside quotes and nested brackets, or -1.
+func closingIndex(s string, from int, close byte) int {
+	var quote byte
+	depth := 0
+	for i := from; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == close && depth == 0:
+			return i
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		}
+	}
+	return -1
+}
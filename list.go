@@ -1,24 +1,24 @@
 package fmter
 
 import (
-	"fmt"
 	"io"
 	"strings"
 )
 
-func writeList[T any](w io.Writer, items []T) error {
+func writeList[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
-	if _, ok := any(items[0]).(Lister); !ok {
-		return fmt.Errorf("%w: format %q requires Lister, not implemented by %T", ErrMissingInterface, List, items[0])
-	}
-	sep := "\n"
-	if s, ok := any(items[0]).(Separator); ok {
-		sep = s.Sep()
+	if err := checkItems(List, items, o); err != nil {
+		return err
 	}
+	sep := o.separatorFor(items[0])
 	var all []string
-	for _, item := range items {
+	for i, item := range items {
+		if data, ok := o.formattedAt(i); ok {
+			all = append(all, strings.TrimSuffix(string(data), "\n"))
+			continue
+		}
 		all = append(all, any(item).(Lister).List()...)
 	}
 	if len(all) == 0 {
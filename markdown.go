@@ -3,42 +3,94 @@ package fmter
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
-
-	"github.com/mattn/go-runewidth"
 )
 
-func writeMarkdown[T any](w io.Writer, items []T) error {
+func writeMarkdown[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
+	if err := checkItems(Markdown, items, o); err != nil {
+		return err
+	}
 	first := any(items[0])
-	if _, ok := first.(Rower); !ok {
-		return fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, Markdown, items[0])
+	header, _ := o.headerOf(first)
+	cols, err := o.columnsFor(first)
+	if err != nil {
+		return err
+	}
+	header = pick(flattenHeader(header, o.headerGroupsFor(first)), cols)
+	footer := footerOf(first, cols)
+	aligns := pick(o.alignsFor(first), cols)
+	aggs := pick(o.aggregatesFor(first), cols)
+	subtotals := pick(o.subtotalsFor(first), cols)
+	var wrapWidths []int
+	if wr, ok := first.(Wrapped); ok {
+		wrapWidths = pick(wr.WrapWidths(), cols)
 	}
-	h, ok := first.(Headed)
-	if !ok {
-		return fmt.Errorf("%w: format %q requires Headed, not implemented by %T", ErrMissingInterface, Markdown, items[0])
+
+	// Apply row numbering by prepending a column, as in tables.
+	n, numbered := first.(Numbered)
+	var lead []string // cells before a group header's label
+	if numbered {
+		header = append([]string{n.NumberHeader()}, header...)
+		aligns = append([]Alignment{AlignRight}, aligns...)
+		if len(footer) > 0 {
+			footer = append([]string{""}, footer...)
+		}
+		if len(aggs) > 0 {
+			aggs = append([]Aggregate{AggregateNone}, aggs...)
+		}
+		if len(subtotals) > 0 {
+			subtotals = append([]Aggregate{AggregateNone}, subtotals...)
+		}
+		if len(wrapWidths) > 0 {
+			wrapWidths = append([]int{0}, wrapWidths...)
+		}
+		lead = []string{""}
 	}
 
-	header := h.Header()
 	numCols := len(header)
+	header = markdownCells(header, nil)
 
-	rows := make([][]string, len(items))
+	_, grouped := first.(Grouped)
+	groupHeader := o.groupHeaderFor(first)
+	rows := make([][]string, 0, len(items))
+	agg := newAggregator(aggs)
+	var sub *aggregator
 	for i, item := range items {
-		rows[i] = any(item).(Rower).Row()
+		row := pick(o.rowAt(i, item), cols)
+		if numbered {
+			row = append([]string{strconv.Itoa(i + 1)}, row...)
+		}
+		if group := groupOf(any(item)); grouped && (i == 0 || group != groupOf(any(items[i-1]))) {
+			// Groups are set off by bold header and subtotal rows.
+			rows = appendBold(rows, sub.footer(nil))
+			if groupHeader != nil {
+				rows = appendBold(rows, append(lead, groupHeader(group)))
+			}
+			sub = newAggregator(subtotals)
+		}
+		agg.add(row)
+		sub.add(row)
+		rows = append(rows, markdownCells(row, wrapWidths))
 	}
+	rows = appendBold(rows, sub.footer(nil))
+	// Markdown has no footer syntax; the footer, with any aggregates, follows
+	// as a bold last row.
+	rows = appendBold(rows, agg.footer(footer))
 
 	// Calculate column widths (minimum 3 for alignment markers).
 	widths := make([]int, numCols)
 	for i, col := range header {
-		if w := runewidth.StringWidth(col); w > widths[i] {
+		if w := displayWidth(col); w > widths[i] {
 			widths[i] = w
 		}
 	}
 	for _, row := range rows {
 		for i, cell := range row {
-			if w := runewidth.StringWidth(cell); i < numCols && w > widths[i] {
+			if w := displayWidth(cell); i < numCols && w > widths[i] {
 				widths[i] = w
 			}
 		}
@@ -49,12 +101,14 @@ func writeMarkdown[T any](w io.Writer, items []T) error {
 		}
 	}
 
-	var aligns []Alignment
-	if a, ok := first.(Aligned); ok {
-		aligns = a.Alignments()
-	}
 	aligns = extendAligns(aligns, numCols)
 
+	if title := o.titleFor(first); title != "" {
+		if _, err := fmt.Fprintf(w, "## %s\n\n", escapeMarkdown(title)); err != nil {
+			return err
+		}
+	}
+
 	if err := writeMarkdownRow(w, header, widths, aligns); err != nil {
 		return err
 	}
@@ -79,6 +133,12 @@ func writeMarkdown[T any](w io.Writer, items []T) error {
 			return err
 		}
 	}
+
+	if caption := o.captionFor(first); caption != "" {
+		if _, err := fmt.Fprintf(w, "\n%s\n", escapeMarkdown(caption)); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -94,3 +154,48 @@ func writeMarkdownRow(w io.Writer, cells []string, widths []int, aligns []Alignm
 	_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(padded, " | "))
 	return err
 }
+
+// appendBold appends cells as a row in bold, when there are any.
+func appendBold(rows [][]string, cells []string) [][]string {
+	if len(cells) == 0 {
+		return rows
+	}
+	row := markdownCells(cells, nil)
+	for i, cell := range row {
+		row[i] = bold(cell)
+	}
+	return append(rows, row)
+}
+
+func bold(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "**" + s + "**"
+}
+
+// markdownEscaper escapes the characters GFM reads as inline markup, and the
+// pipe that would end a table cell.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "|", `\|`, "*", `\*`, "_", `\_`, "`", "\\`",
+	"[", `\[`, "]", `\]`, "<", `\<`, ">", `\>`,
+)
+
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownCells returns cells escaped for a GFM table row. Table cells can't
+// hold line breaks, so multi-line cells, and cells wrapped at their
+// [Wrapped] width, have their lines joined by <br> tags.
+func markdownCells(cells []string, wrapWidths []int) []string {
+	out := make([]string, len(cells))
+	for i, cell := range cells {
+		lines := wrapCell(cell, at(wrapWidths, i))
+		for j, line := range lines {
+			lines[j] = escapeMarkdown(line)
+		}
+		out[i] = strings.Join(lines, "<br>")
+	}
+	return out
+}
@@ -0,0 +1,140 @@
+package fmter
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+)
+
+// Mixed selects how tabular formats (CSV, TSV, Table, Markdown, HTML,
+// Vertical) render items of different concrete types, such as a []Resource
+// holding several kinds. Items are told apart by their dynamic Go type.
+type Mixed int
+
+const (
+	// MixedFirst applies the first item's header to every row. It is the
+	// default.
+	MixedFirst Mixed = iota
+	// MixedUnion renders a single table whose columns are the union of every
+	// type's header, in order of first appearance. Columns a type lacks are
+	// left empty. Every type needs a header ([Headed] or struct tags).
+	MixedUnion
+	// MixedSplit renders a separate table for each type, with its own header,
+	// in order of first appearance and separated by a blank line.
+	MixedSplit
+)
+
+// WithMixed sets how items of different concrete types are rendered. With
+// [MixedUnion], alignments, maximum widths, and [Aggregated] and [Subtotaled]
+// aggregates follow each type's columns, taken from the first type with each
+// column; footers and styles come from the first item. [HeaderGrouped] types
+// can't be combined with MixedUnion, since their groups need not be adjacent
+// in the union, and fail with [ErrInvalidFormat] unless [WithHeaderGroups]
+// sets groups for the union's columns.
+func WithMixed(m Mixed) Option {
+	return func(o *options) { o.mixed = m }
+}
+
+func isTabular(f Format) bool {
+	switch f {
+	case CSV, TSV, Table, Markdown, HTML, Vertical:
+		return true
+	}
+	return false
+}
+
+// kindsOf groups items by dynamic type in order of first appearance and
+// returns the index of each group's first item.
+func kindsOf[T any](items []T) (groups [][]T, firsts []int) {
+	group := map[reflect.Type]int{}
+	for i, item := range items {
+		t := reflect.TypeOf(any(item))
+		g, ok := group[t]
+		if !ok {
+			g = len(groups)
+			group[t] = g
+			groups = append(groups, nil)
+			firsts = append(firsts, i)
+		}
+		groups[g] = append(groups[g], item)
+	}
+	return groups, firsts
+}
+
+func writeSplit[T any](w io.Writer, f Format, groups [][]T, o *options) error {
+	for i, group := range groups {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if err := writeItems(w, f, group, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unionColumns returns options that render items, whose types' first items
+// are at firsts, against the union of their headers.
+func unionColumns[T any](f Format, items []T, firsts []int, o *options) (*options, error) {
+	var header []string
+	var aligns []Alignment
+	var widths []int
+	var aggs, subtotals []Aggregate
+	aggregated, subtotaled := false, false
+	positions := map[reflect.Type][]int{}
+	for _, i := range firsts {
+		v := any(items[i])
+		kindHeader, ok := o.headerOf(v)
+		if !ok {
+			return nil, newMissingInterfaceError[T](f, v, i, "Headed")
+		}
+		if _, ok := v.(HeaderGrouped); ok && o.headerGroups == nil {
+			return nil, fmt.Errorf("%w: %T has header groups, which MixedUnion can't lay out; use WithHeaderGroups", ErrInvalidFormat, v)
+		}
+		kindAligns, kindWidths := alignsOf(v), maxWidthsOf(v)
+		var kindAggs, kindSubtotals []Aggregate
+		if a, ok := v.(Aggregated); ok {
+			kindAggs, aggregated = a.Aggregates(), true
+		}
+		if s, ok := v.(Subtotaled); ok {
+			kindSubtotals, subtotaled = s.Subtotals(), true
+		}
+		pos := make([]int, len(kindHeader))
+		for j, name := range kindHeader {
+			k := slices.Index(header, name)
+			if k < 0 {
+				k = len(header)
+				header = append(header, name)
+				aligns = append(aligns, at(kindAligns, j))
+				widths = append(widths, at(kindWidths, j))
+				aggs = append(aggs, at(kindAggs, j))
+				subtotals = append(subtotals, at(kindSubtotals, j))
+			}
+			pos[j] = k
+		}
+		positions[reflect.TypeOf(v)] = pos
+	}
+
+	co := *o
+	co.headerSource, co.alignSource, co.widthSource = header, aligns, widths
+	if aggregated {
+		co.aggregateSource = aggs
+	}
+	if subtotaled {
+		co.subtotalSource = subtotals
+	}
+	co.rowSource = func(v any) []string {
+		row := make([]string, len(header))
+		pos := positions[reflect.TypeOf(v)]
+		for j, cell := range o.rowOf(v) {
+			if j < len(pos) {
+				row[pos[j]] = cell
+			}
+		}
+		return row
+	}
+	return &co, nil
+}
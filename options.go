@@ -0,0 +1,284 @@
+package fmter
+
+import "fmt"
+
+// Option overrides rendering metadata for a single write. Values set through
+// options take precedence over those provided by the optional interfaces, so
+// CLI flags such as --border or --no-headers win over type defaults.
+type Option func(*options)
+
+type options struct {
+	border       *BorderStyle
+	borderSet    *BorderSet
+	indent       *string
+	aligns       []Alignment
+	aggregates   []Aggregate
+	subtotals    []Aggregate
+	groupHeaders *bool
+	headerGroups []HeaderGroup
+	title        *string
+	caption      *string
+	pageSize     *int
+	maxWidth     *int
+	theme        *Theme
+	color        *bool
+	delimiter    *rune
+	separator    *string
+	export       *bool
+	quote        *bool
+	noHeaders    bool
+	columns      []string
+	sort         bool
+	tree         bool
+	sortBy       []string
+
+	streamTable *streamTableOptions
+	mixed       Mixed
+
+	// Set by parameterized formats that synthesize rows, such as
+	// custom-columns, in place of [Rower] and [Headed].
+	rowSource    func(v any) []string
+	rowCheck     func(v any) error // reports items rowSource can't read
+	headerSource []string
+	alignSource  []Alignment
+	widthSource  []int
+
+	// Set by MixedUnion, which also sets the sources above, from the
+	// aggregates of every item type.
+	aggregateSource []Aggregate
+	subtotalSource  []Aggregate
+
+	// Set by writeFormatted, by item index, for items whose [Formatter]
+	// returned output.
+	formatted     [][]byte
+	formattedRows [][]string
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithBorder overrides [Bordered].
+func WithBorder(b BorderStyle) Option {
+	return func(o *options) { o.border, o.borderSet = &b, nil }
+}
+
+// WithIndent overrides [Indented].
+func WithIndent(indent string) Option {
+	return func(o *options) { o.indent = &indent }
+}
+
+// WithAlignments overrides [Aligned].
+func WithAlignments(aligns ...Alignment) Option {
+	return func(o *options) { o.aligns = aligns }
+}
+
+// WithTitle overrides [Titled]. An empty title suppresses it.
+func WithTitle(title string) Option {
+	return func(o *options) { o.title = &title }
+}
+
+// WithCaption overrides [Captioned]. An empty caption suppresses it.
+func WithCaption(caption string) Option {
+	return func(o *options) { o.caption = &caption }
+}
+
+// WithPageSize overrides [Paged]. Zero disables header repetition.
+func WithPageSize(n int) Option {
+	return func(o *options) { o.pageSize = &n }
+}
+
+// WithDelimiter overrides [Delimited].
+func WithDelimiter(d rune) Option {
+	return func(o *options) { o.delimiter = &d }
+}
+
+// WithSeparator overrides [Separator].
+func WithSeparator(sep string) Option {
+	return func(o *options) { o.separator = &sep }
+}
+
+// WithExport overrides [Exported].
+func WithExport(export bool) Option {
+	return func(o *options) { o.export = &export }
+}
+
+// WithQuote overrides [Quoted].
+func WithQuote(quote bool) Option {
+	return func(o *options) { o.quote = &quote }
+}
+
+// WithNoHeaders suppresses the header row provided by [Headed] in CSV, TSV,
+// Table, and HTML. Markdown always renders a header row because GFM tables
+// require one.
+func WithNoHeaders() Option {
+	return func(o *options) { o.noHeaders = true }
+}
+
+func (o *options) borderFor(v any) BorderStyle {
+	if o.border != nil {
+		return *o.border
+	}
+	if b, ok := v.(Bordered); ok {
+		return b.Border()
+	}
+	return BorderRounded
+}
+
+func (o *options) indentFor(v any) (string, bool) {
+	if o.indent != nil {
+		return *o.indent, true
+	}
+	if ind, ok := v.(Indented); ok {
+		return ind.Indent(), true
+	}
+	return "", false
+}
+
+func (o *options) alignsFor(v any) []Alignment {
+	if o.aligns != nil {
+		return o.aligns
+	}
+	if o.alignSource != nil {
+		return o.alignSource
+	}
+	if o.rowSource != nil {
+		// align= tag options sit on fields the row source may have reordered or
+		// dropped, so only an explicit Alignments method still applies.
+		if a, ok := v.(Aligned); ok {
+			return a.Alignments()
+		}
+		return nil
+	}
+	return alignsOf(v)
+}
+
+func (o *options) maxWidthsFor(v any) []int {
+	if o.widthSource != nil {
+		return o.widthSource
+	}
+	if o.rowSource != nil {
+		if tr, ok := v.(Truncated); ok {
+			return tr.MaxWidths()
+		}
+		return nil
+	}
+	return maxWidthsOf(v)
+}
+
+func (o *options) titleFor(v any) string {
+	if o.title != nil {
+		return *o.title
+	}
+	if t, ok := v.(Titled); ok {
+		return t.Title()
+	}
+	return ""
+}
+
+func (o *options) captionFor(v any) string {
+	if o.caption != nil {
+		return *o.caption
+	}
+	if c, ok := v.(Captioned); ok {
+		return c.Caption()
+	}
+	return ""
+}
+
+func (o *options) pageSizeFor(v any) int {
+	if o.pageSize != nil {
+		return *o.pageSize
+	}
+	if p, ok := v.(Paged); ok {
+		return p.PageSize()
+	}
+	return 0
+}
+
+func (o *options) delimiterFor(v any) rune {
+	if o.delimiter != nil {
+		return *o.delimiter
+	}
+	if d, ok := v.(Delimited); ok {
+		return d.Delimiter()
+	}
+	return ','
+}
+
+func (o *options) separatorFor(v any) string {
+	if o.separator != nil {
+		return *o.separator
+	}
+	if s, ok := v.(Separator); ok {
+		return s.Sep()
+	}
+	return "\n"
+}
+
+func (o *options) exportFor(v any) bool {
+	if o.export != nil {
+		return *o.export
+	}
+	if e, ok := v.(Exported); ok {
+		return e.Export()
+	}
+	return false
+}
+
+func (o *options) quoteFor(v any) bool {
+	if o.quote != nil {
+		return *o.quote
+	}
+	if q, ok := v.(Quoted); ok {
+		return q.Quote()
+	}
+	return false
+}
+
+// headerFor returns the header row, or nil when headers are suppressed or v
+// has neither [Headed] nor struct tags.
+func (o *options) headerFor(v any) []string {
+	if o.noHeaders {
+		return nil
+	}
+	header, _ := o.headerOf(v)
+	return header
+}
+
+// rowOf returns the row for v from the row source that custom-columns or
+// [MixedUnion] installs, or otherwise from v's [Rower] Row method or its
+// struct tags.
+func (o *options) rowOf(v any) []string {
+	if o.rowSource != nil {
+		return o.rowSource(v)
+	}
+	return rowOf(v)
+}
+
+// checkRow returns the error, if any, that keeps the row source from reading
+// the item v at index i.
+func (o *options) checkRow(v any, i int) error {
+	if o.rowCheck == nil {
+		return nil
+	}
+	if err := o.rowCheck(v); err != nil {
+		return fmt.Errorf("item %d: %w", i, err)
+	}
+	return nil
+}
+
+func (o *options) isRower(v any) bool {
+	return o.rowSource != nil || isRower(v)
+}
+
+func (o *options) headerOf(v any) ([]string, bool) {
+	if o.rowSource != nil {
+		return o.headerSource, true
+	}
+	return headerOf(v)
+}
@@ -5,17 +5,25 @@ import (
 	"io"
 )
 
-func writePlain[T any](w io.Writer, items []T) error {
-	for _, item := range items {
-		var s string
-		if str, ok := any(item).(fmt.Stringer); ok {
-			s = str.String()
-		} else {
-			s = fmt.Sprintf("%v", item)
+func writePlain[T any](w io.Writer, items []T, o *options) error {
+	for i, item := range items {
+		if data, ok := o.formattedAt(i); ok {
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			continue
 		}
-		if _, err := fmt.Fprintln(w, s); err != nil {
+		if _, err := fmt.Fprintln(w, stringOf(item)); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// stringOf returns v's [fmt.Stringer] output, or its default %v format.
+func stringOf(v any) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,67 @@
+package fmter
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// quantityKind distinguishes cells that compare numerically.
+type quantityKind int
+
+const (
+	quantityNumber quantityKind = iota
+	quantityDuration
+	quantityBytes
+)
+
+// quantity is a cell parsed into a comparable value. Durations are stored in
+// nanoseconds and byte sizes in bytes.
+type quantity struct {
+	value float64
+	kind  quantityKind
+}
+
+var byteUnits = map[string]float64{
+	"b":  1,
+	"kb": 1e3, "mb": 1e6, "gb": 1e9, "tb": 1e12, "pb": 1e15,
+	"kib": 1 << 10, "mib": 1 << 20, "gib": 1 << 30, "tib": 1 << 40, "pib": 1 << 50,
+	"ki": 1 << 10, "mi": 1 << 20, "gi": 1 << 30, "ti": 1 << 40, "pi": 1 << 50,
+}
+
+// parseQuantity recognizes plain numbers (with optional thousands separators
+// or a trailing %), Go durations such as "1h30m", and byte sizes such as
+// "1.2 GiB" or "512MB".
+func parseQuantity(s string) (quantity, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return quantity{}, false
+	}
+	if n, ok := parseNumber(strings.TrimSuffix(s, "%")); ok {
+		return quantity{value: n, kind: quantityNumber}, true
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return quantity{value: float64(d), kind: quantityDuration}, true
+	}
+	split := strings.LastIndexAny(s, "0123456789.") + 1
+	if split == 0 {
+		return quantity{}, false
+	}
+	n, ok := parseNumber(s[:split])
+	mult, unit := byteUnits[strings.ToLower(strings.TrimSpace(s[split:]))]
+	if !ok || !unit {
+		return quantity{}, false
+	}
+	return quantity{value: n * mult, kind: quantityBytes}, true
+}
+
+// parseNumber parses a decimal number. Words that ParseFloat would accept,
+// such as "Inf" or "NaN", are rejected so they keep sorting as text.
+func parseNumber(s string) (float64, bool) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" || !strings.ContainsAny(s[:1], "+-.0123456789") {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n, err == nil
+}
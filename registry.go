@@ -0,0 +1,110 @@
+package fmter
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"sync"
+)
+
+// Renderer renders a batch of items in a registered format. Items are passed
+// as []any because registered formats are not tied to a single item type.
+type Renderer func(w io.Writer, items []any) error
+
+// StreamRenderer renders items from a sequence as they arrive. Formats
+// registered without one are collected and passed to their [Renderer].
+type StreamRenderer func(w io.Writer, seq iter.Seq[any]) error
+
+// RegisterOption configures a format registered with [RegisterFormat].
+type RegisterOption func(*registeredFormat)
+
+// WithStreamRenderer sets the renderer used by [WriteIter] and [WriteChan].
+func WithStreamRenderer(s StreamRenderer) RegisterOption {
+	return func(r *registeredFormat) { r.stream = s }
+}
+
+// WithSupports sets the capability check used by [IsSupported]. The function
+// receives the zero value of the item type. Without it, the format is
+// reported as supported for every type.
+func WithSupports(fn func(v any) bool) RegisterOption {
+	return func(r *registeredFormat) { r.supports = fn }
+}
+
+type registeredFormat struct {
+	render   Renderer
+	stream   StreamRenderer
+	supports func(v any) bool
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Format]*registeredFormat{}
+	registered []Format
+)
+
+// RegisterFormat adds a third-party format. Once registered, f is recognized
+// by [ParseFormat], listed by [Formats], and rendered by [Write], [Marshal],
+// [WriteIter], and [WriteChan] like any built-in format. Registering a name
+// that is empty, already registered, or taken by a built-in format fails.
+func RegisterFormat(f Format, r Renderer, opts ...RegisterOption) error {
+	if f == "" || r == nil {
+		return fmt.Errorf("%w: format name and renderer are required", ErrInvalidFormat)
+	}
+	if isParameterized(f) {
+		return fmt.Errorf("%w: %q uses a reserved prefix", ErrInvalidFormat, f)
+	}
+	if isBuiltin(f) {
+		return fmt.Errorf("%w: %q", ErrFormatExists, f)
+	}
+	rf := &registeredFormat{render: r}
+	for _, opt := range opts {
+		opt(rf)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, ok := registry[f]; ok {
+		return fmt.Errorf("%w: %q", ErrFormatExists, f)
+	}
+	registry[f] = rf
+	registered = append(registered, f)
+	return nil
+}
+
+func isBuiltin(f Format) bool {
+	for _, b := range formats {
+		if b == f {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupFormat(f Format) (*registeredFormat, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	rf, ok := registry[f]
+	return rf, ok
+}
+
+func registeredFormats() []Format {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]Format, len(registered))
+	copy(out, registered)
+	return out
+}
+
+func writeRegistered[T any](w io.Writer, rf *registeredFormat, items []T) error {
+	all := make([]any, len(items))
+	for i, item := range items {
+		all[i] = item
+	}
+	return rf.render(w, all)
+}
+
+func streamRegistered[T any](w io.Writer, rf *registeredFormat, seq iter.Seq[T]) error {
+	return rf.stream(w, func(yield func(any) bool) {
+		seq(func(item T) bool { return yield(item) })
+	})
+}
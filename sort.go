@@ -0,0 +1,127 @@
+package fmter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// WithSort enables the sort stage, ordering items by the column declared
+// with [Sorted]. Items that do not implement [Sorted] keep their order.
+func WithSort() Option {
+	return func(o *options) { o.sort = true }
+}
+
+// WithSortBy enables the sort stage with explicit keys, overriding [Sorted].
+// Each key is a header name, matched case-insensitively; a leading "-" sorts
+// descending. Keys may also be given as a single comma-separated string, as
+// from a --sort-by flag:
+//
+//	fmter.WithSortBy("name,-port")
+//
+// Cells that look like numbers, durations, or byte sizes compare by value;
+// other cells compare as text. Sorting is stable and applies to every format,
+// including JSON and YAML, as long as the items provide rows and a header.
+func WithSortBy(keys ...string) Option {
+	return func(o *options) {
+		o.sort = true
+		o.sortBy = nil
+		for _, key := range keys {
+			for k := range strings.SplitSeq(key, ",") {
+				if k = strings.TrimSpace(k); k != "" {
+					o.sortBy = append(o.sortBy, k)
+				}
+			}
+		}
+	}
+}
+
+type sortKey struct {
+	col  int
+	desc bool
+}
+
+// sortKeysFor resolves the sort keys against v's header.
+func (o *options) sortKeysFor(v any) ([]sortKey, error) {
+	if o.sortBy == nil {
+		if s, ok := v.(Sorted); ok {
+			col, desc := s.Sort()
+			return []sortKey{{col: col, desc: desc}}, nil
+		}
+		return nil, nil
+	}
+	header, _ := o.headerOf(v)
+	keys := make([]sortKey, len(o.sortBy))
+	for i, name := range o.sortBy {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimLeft(name, "+-")
+		col := columnIndex(header, name)
+		if col < 0 {
+			if len(header) == 0 {
+				return nil, fmt.Errorf("%w: %q (%T has no header)", ErrUnknownColumn, name, v)
+			}
+			return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownColumn, name, strings.Join(header, ", "))
+		}
+		keys[i] = sortKey{col: col, desc: desc}
+	}
+	return keys, nil
+}
+
+// sortItems returns a stably sorted copy of items when sorting is enabled.
+// The input slice is never modified.
+func sortItems[T any](items []T, o *options) ([]T, error) {
+	if !o.sort || len(items) == 0 {
+		return items, nil
+	}
+	keys, err := o.sortKeysFor(any(items[0]))
+	if err != nil || len(keys) == 0 {
+		return items, err
+	}
+	rows := make([][]string, len(items))
+	order := make([]int, len(items))
+	for i, item := range items {
+		rows[i] = o.rowOf(item)
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		for _, k := range keys {
+			c := compareCells(at(rows[a], k.col), at(rows[b], k.col))
+			if k.desc {
+				c = -c
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	sorted := make([]T, len(items))
+	for i, idx := range order {
+		sorted[i] = items[idx]
+	}
+	return sorted, nil
+}
+
+// compareCells orders two cells naturally: numbers, durations, and byte sizes
+// by value, and everything else as case-insensitive text. Quantities sort
+// before text.
+func compareCells(a, b string) int {
+	qa, okA := parseQuantity(a)
+	qb, okB := parseQuantity(b)
+	switch {
+	case okA && okB:
+		if c := cmp.Compare(qa.kind, qb.kind); c != 0 {
+			return c
+		}
+		return cmp.Compare(qa.value, qb.value)
+	case okA:
+		return -1
+	case okB:
+		return 1
+	}
+	if c := strings.Compare(strings.ToLower(a), strings.ToLower(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
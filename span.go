@@ -0,0 +1,150 @@
+package fmter
+
+// HeaderGroup titles a run of adjacent columns in the header row added by
+// [HeaderGrouped]. Groups are laid out left to right; an empty Title leaves
+// its columns ungrouped.
+type HeaderGroup struct {
+	Title string
+	Span  int // columns covered; values below 2 cover one column
+}
+
+// WithHeaderGroups overrides [HeaderGrouped].
+func WithHeaderGroups(groups ...HeaderGroup) Option {
+	return func(o *options) { o.headerGroups = groups }
+}
+
+func (o *options) headerGroupsFor(v any) []HeaderGroup {
+	if o.headerGroups != nil {
+		return o.headerGroups
+	}
+	if o.rowSource != nil {
+		// HeaderGroups() spans the item's own header, which the row
+		// source has replaced.
+		return nil
+	}
+	if g, ok := v.(HeaderGrouped); ok {
+		return g.HeaderGroups()
+	}
+	return nil
+}
+
+// cellOwner returns the index of the cell of a [Spanned] row that covers
+// column col.
+func cellOwner(spans []int, col int) int {
+	for i := 0; i < len(spans); i += max(spans[i], 1) {
+		if col < i+max(spans[i], 1) {
+			return i
+		}
+	}
+	return col
+}
+
+// groupOwner returns the index of the header group covering column col, or
+// -1 when no group does.
+func groupOwner(groups []HeaderGroup, col int) int {
+	end := 0
+	for i, g := range groups {
+		end += max(g.Span, 1)
+		if col < end {
+			return i
+		}
+	}
+	return -1
+}
+
+// ownerSpans merges runs of adjacent columns with the same owner into one
+// cell, returning the number of columns covered by the cell at the start of
+// each run and 0 for the columns it covers. Negative owners never merge. It
+// returns nil when every cell covers one column.
+func ownerSpans(owners []int) []int {
+	spans := make([]int, len(owners))
+	merged := false
+	start := 0
+	for i, owner := range owners {
+		if i > 0 && owner >= 0 && owner == owners[start] {
+			spans[start]++
+			merged = true
+			continue
+		}
+		spans[i], start = 1, i
+	}
+	if !merged {
+		return nil
+	}
+	return spans
+}
+
+// itemColumn returns the index into the item's row of displayed column i
+// under the column selection cols.
+func itemColumn(cols []int, i int) int {
+	if cols != nil {
+		return cols[i]
+	}
+	return i
+}
+
+// rowSpans returns the spans of v's n displayed cells, or nil when v is not
+// [Spanned] or none of its cells span columns. column maps a displayed
+// column to the item's column, or to -1 for one the item has no part in.
+//
+// A merged cell shows the value of its first displayed column, so columns
+// only merge into a run that starts at the spanning cell itself; columns
+// displayed apart from it, or before it, stay separate.
+func rowSpans(v any, n int, column func(int) int) []int {
+	sp, ok := v.(Spanned)
+	if !ok {
+		return nil
+	}
+	spans := sp.Spans()
+	owners := make([]int, n)
+	for i := range owners {
+		owners[i] = -1
+		if col := column(i); col >= 0 {
+			if owner := cellOwner(spans, col); owner == col || (i > 0 && owners[i-1] == owner) {
+				owners[i] = owner
+			}
+		}
+	}
+	return ownerSpans(owners)
+}
+
+// groupRow returns the header group row over n displayed columns: each
+// group's title in its first column, and the spans of the groups. column is
+// as for [rowSpans].
+func groupRow(groups []HeaderGroup, n int, column func(int) int) ([]string, []int) {
+	cells := make([]string, n)
+	owners := make([]int, n)
+	for i := range owners {
+		owners[i] = -1
+		if col := column(i); col >= 0 {
+			if g := groupOwner(groups, col); g >= 0 && groups[g].Title != "" {
+				owners[i] = g
+			}
+		}
+		if owners[i] >= 0 && (i == 0 || owners[i] != owners[i-1]) {
+			cells[i] = groups[owners[i]].Title
+		}
+	}
+	return cells, ownerSpans(owners)
+}
+
+// flattenHeader prefixes each column of header with the title of its header
+// group, for formats with a single header row.
+func flattenHeader(header []string, groups []HeaderGroup) []string {
+	if groups == nil || header == nil {
+		return header
+	}
+	flat := make([]string, len(header))
+	for i, h := range header {
+		flat[i] = h
+		if g := groupOwner(groups, i); g >= 0 && groups[g].Title != "" {
+			flat[i] = groups[g].Title + " " + h
+		}
+	}
+	return flat
+}
+
+// flatHeaderFor returns v's header flattened by [flattenHeader].
+func (o *options) flatHeaderFor(v any) []string {
+	return flattenHeader(o.headerFor(v), o.headerGroupsFor(v))
+}
@@ -1,6 +1,7 @@
 package fmter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,44 +9,171 @@ import (
 	"strings"
 )
 
-// WriteIter formats items from an iterator and writes them to w as they arrive.
-// For formats where items are independent (JSONL, CSV, TSV, List, ENV,
-// GoTemplate, Plain), each item is written immediately. For formats that need
-// all data for layout (Table, Markdown, HTML), items are collected into a slice
-// first. For JSON, items are streamed as array elements. For YAML, items are
-// collected (the encoder needs a complete document).
-func WriteIter[T any](w io.Writer, f Format, seq iter.Seq[T]) error {
+// WriteIter formats items from an iterator and writes them to w as they
+// arrive. For formats where items are independent (JSONL, CSV, TSV,
+// JSONPath, Plain, Vertical), each item is written immediately. For formats
+// that need all data for layout (Table, Markdown, HTML, Tree), items are
+// collected into a slice first, unless [WithStreamingTable] is given for
+// Table. For JSON, items are streamed as array elements. For YAML, items are
+// collected (the encoder needs a complete document). Registered formats use
+// their [StreamRenderer] if they have one and are collected otherwise.
+// Options apply as in [WriteWith].
+func WriteIter[T any](w io.Writer, f Format, seq iter.Seq[T], opts ...Option) error {
+	o := newOptions(opts)
+	if o.sort || (o.mixed != MixedFirst && isTabular(f)) {
+		// Sorting and mixed-type layouts need every item before the first
+		// can be written.
+		return streamCollect(w, f, seq, o)
+	}
 	switch f {
 	case JSON:
-		return streamJSON(w, seq)
+		return streamJSON(w, seq, o)
 	case YAML:
-		return streamCollect(w, f, seq)
-	case Table, Markdown, HTML:
-		return streamCollect(w, f, seq)
+		return streamCollect(w, f, seq, o)
+	case Table:
+		if o.streamTable != nil {
+			return streamTable(w, seq, o)
+		}
+		return streamCollect(w, f, seq, o)
+	case Markdown, HTML, Tree:
+		return streamCollect(w, f, seq, o)
 	case CSV:
-		return streamCSV(w, seq)
+		return streamCSV(w, seq, o)
 	case TSV:
-		return streamTSV(w, seq)
+		return streamTSV(w, seq, o)
 	case JSONL:
-		return streamJSONL(w, seq)
+		return streamJSONL(w, seq, o)
+	case Vertical:
+		return streamVertical(w, seq, o)
 	case Plain:
 		return streamPlain(w, seq)
 	case List:
-		return streamCollect(w, f, seq)
+		return streamCollect(w, f, seq, o)
 	case ENV:
-		return streamCollect(w, f, seq)
+		return streamCollect(w, f, seq, o)
 	default:
-		if tmpl, ok := strings.CutPrefix(string(f), goTemplatePrefix); ok {
-			return streamGoTemplate(w, tmpl, seq)
+		if strings.HasPrefix(string(f), goTemplatePrefix) {
+			return streamCollect(w, f, seq, o)
+		}
+		if tmpl, ok := strings.CutPrefix(string(f), jsonPathPrefix); ok {
+			return streamJSONPath(w, f, tmpl, seq)
+		}
+		if isCustomColumns(f) {
+			if o.streamTable != nil {
+				co, err := customColumnsTable(f, o)
+				if err != nil {
+					return err
+				}
+				return streamTable(w, seq, co)
+			}
+			return streamCollect(w, f, seq, o)
+		}
+		if rf, ok := lookupFormat(f); ok {
+			if rf.stream == nil {
+				return streamCollect(w, f, seq, o)
+			}
+			return streamRegistered(w, rf, seq)
 		}
 		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, f)
 	}
 }
 
+// WriteIter2 is like [WriteIter] for sources that can fail mid-stream, such
+// as paginated API calls. When seq yields a non-nil error, writing stops and
+// the output written so far is closed off (JSON arrays, HTML tables, and
+// table borders are completed) before the producer's error is returned,
+// wrapped with the zero-based index of the failed item. Errors from writing
+// take precedence.
+func WriteIter2[T any](w io.Writer, f Format, seq iter.Seq2[T, error], opts ...Option) error {
+	var index int
+	var seqErr error
+	items := func(yield func(T) bool) {
+		for item, err := range seq {
+			if err != nil {
+				seqErr = err
+				return
+			}
+			if !yield(item) {
+				return
+			}
+			index++
+		}
+	}
+	if err := WriteIter(w, f, items, opts...); err != nil {
+		return err
+	}
+	if seqErr != nil {
+		return fmt.Errorf("item %d: %w", index, seqErr)
+	}
+	return nil
+}
+
 // WriteChan formats items from a channel and writes them to w.
 // It is a thin wrapper around [WriteIter].
-func WriteChan[T any](w io.Writer, f Format, ch <-chan T) error {
-	return WriteIter(w, f, chanToIter(ch))
+func WriteChan[T any](w io.Writer, f Format, ch <-chan T, opts ...Option) error {
+	return WriteIter(w, f, chanToIter(ch), opts...)
+}
+
+// WriteIterContext is like [WriteIter] but stops when ctx is canceled or its
+// deadline passes. Output written so far is closed off as in [WriteIter2] and
+// ctx.Err() is returned. seq is only interrupted when it next yields, so a
+// source that can block indefinitely should use [WriteChanContext].
+func WriteIterContext[T any](ctx context.Context, w io.Writer, f Format, seq iter.Seq[T], opts ...Option) error {
+	var stopped error
+	items := func(yield func(T) bool) {
+		for item := range seq {
+			if stopped = ctx.Err(); stopped != nil || !yield(item) {
+				return
+			}
+		}
+	}
+	if err := WriteIter(w, f, items, opts...); err != nil {
+		return err
+	}
+	return stopped
+}
+
+// WriteIter2Context is like [WriteIter2] but also stops when ctx is done,
+// returning ctx.Err().
+func WriteIter2Context[T any](ctx context.Context, w io.Writer, f Format, seq iter.Seq2[T, error], opts ...Option) error {
+	var stopped error
+	items := func(yield func(T, error) bool) {
+		for item, err := range seq {
+			if stopped = ctx.Err(); stopped != nil || !yield(item, err) {
+				return
+			}
+		}
+	}
+	if err := WriteIter2(w, f, items, opts...); err != nil {
+		return err
+	}
+	return stopped
+}
+
+// WriteChanContext is like [WriteChan] but stops when ctx is done, even while
+// waiting on a channel that is never closed, and returns ctx.Err().
+func WriteChanContext[T any](ctx context.Context, w io.Writer, f Format, ch <-chan T, opts ...Option) error {
+	var stopped error
+	items := func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				stopped = ctx.Err()
+				return
+			case item, ok := <-ch:
+				if !ok {
+					return
+				}
+				if stopped = ctx.Err(); stopped != nil || !yield(item) {
+					return
+				}
+			}
+		}
+	}
+	if err := WriteIter(w, f, items, opts...); err != nil {
+		return err
+	}
+	return stopped
 }
 
 func chanToIter[T any](ch <-chan T) iter.Seq[T] {
@@ -58,7 +186,7 @@ func chanToIter[T any](ch <-chan T) iter.Seq[T] {
 	}
 }
 
-func streamCollect[T any](w io.Writer, f Format, seq iter.Seq[T]) error {
+func streamCollect[T any](w io.Writer, f Format, seq iter.Seq[T], o *options) error {
 	var items []T
 	seq(func(item T) bool {
 		items = append(items, item)
@@ -67,29 +195,41 @@ func streamCollect[T any](w io.Writer, f Format, seq iter.Seq[T]) error {
 	if len(items) == 0 {
 		return nil
 	}
-	return Write(w, f, items...)
+	return writeItems(w, f, items, o)
 }
 
-func streamJSON[T any](w io.Writer, seq iter.Seq[T]) error {
+func streamJSON[T any](w io.Writer, seq iter.Seq[T], o *options) error {
 	if _, err := io.WriteString(w, "["); err != nil {
 		return err
 	}
-	first := true
+	index := 0
 	var encErr error
 	seq(func(item T) bool {
-		if !first {
+		i := index
+		index++
+		var elem any = item
+		data, err := formatItem(JSON, item)
+		if err == nil && data != nil {
+			elem, err = jsonElement(data, i)
+		} else if err == nil && o.nests(item) {
+			elem, err = jsonTree(item)
+		}
+		if err != nil {
+			encErr = err
+			return false
+		}
+		if i > 0 {
 			if _, err := io.WriteString(w, ","); err != nil {
 				encErr = err
 				return false
 			}
 		}
-		first = false
 		enc := json.NewEncoder(w)
 		enc.SetEscapeHTML(false)
-		if ind, ok := any(item).(Indented); ok {
-			enc.SetIndent("", ind.Indent())
+		if indent, ok := o.indentFor(item); ok {
+			enc.SetIndent("", indent)
 		}
-		if err := enc.Encode(item); err != nil {
+		if err := enc.Encode(elem); err != nil {
 			encErr = err
 			return false
 		}
@@ -102,49 +242,77 @@ func streamJSON[T any](w io.Writer, seq iter.Seq[T]) error {
 	return err
 }
 
-func streamCSV[T any](w io.Writer, seq iter.Seq[T]) error {
-	first := true
+func streamCSV[T any](w io.Writer, seq iter.Seq[T], o *options) error {
+	index := 0
+	var comma rune
+	var cols []int
+	var footer []string
+	var agg *aggregator
 	var streamErr error
 	seq(func(item T) bool {
-		if first {
-			first = false
-			if _, ok := any(item).(Rower); !ok {
-				streamErr = fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, CSV, item)
-				return false
-			}
-			if err := Write(w, CSV, item); err != nil {
+		i := index
+		index++
+		if i == 0 {
+			comma = o.delimiterFor(item)
+		}
+		row, err := streamRow(CSV, item, i, comma, o)
+		if err != nil {
+			streamErr = err
+			return false
+		}
+		if i == 0 {
+			if cols, err = o.columnsFor(item); err != nil {
 				streamErr = err
 				return false
 			}
-			return true
+			if header := o.flatHeaderFor(item); header != nil {
+				if err := writeCSVRow(w, pick(header, cols), comma); err != nil {
+					streamErr = err
+					return false
+				}
+			}
+			footer = footerOf(item, cols)
+			agg = newAggregator(pick(o.aggregatesFor(item), cols))
 		}
-		// Subsequent items: write row only (header already written by first Write).
-		if err := writeCSVRow(w, any(item).(Rower).Row()); err != nil {
+		row = pick(row, cols)
+		agg.add(row)
+		if err := writeCSVRow(w, row, comma); err != nil {
 			streamErr = err
 			return false
 		}
 		return true
 	})
-	return streamErr
+	if streamErr != nil || agg == nil {
+		return streamErr
+	}
+	return writeCSVRow(w, agg.footer(footer), comma)
 }
 
-func streamTSV[T any](w io.Writer, seq iter.Seq[T]) error {
-	first := true
+func streamTSV[T any](w io.Writer, seq iter.Seq[T], o *options) error {
+	index := 0
+	var cols []int
 	var streamErr error
 	seq(func(item T) bool {
-		if first {
-			first = false
-			if _, ok := any(item).(Rower); !ok {
-				streamErr = fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, TSV, item)
-				return false
-			}
-			if err := Write(w, TSV, item); err != nil {
+		i := index
+		index++
+		row, err := streamRow(TSV, item, i, 0, o)
+		if err != nil {
+			streamErr = err
+			return false
+		}
+		if i == 0 {
+			if cols, err = o.columnsFor(item); err != nil {
 				streamErr = err
 				return false
 			}
-			return true
+			if header := o.flatHeaderFor(item); header != nil {
+				if _, err := fmt.Fprintln(w, strings.Join(pick(header, cols), "\t")); err != nil {
+					streamErr = err
+					return false
+				}
+			}
 		}
-		if _, err := fmt.Fprintln(w, strings.Join(any(item).(Rower).Row(), "\t")); err != nil {
+		if _, err := fmt.Fprintln(w, strings.Join(pick(row, cols), "\t")); err != nil {
 			streamErr = err
 			return false
 		}
@@ -153,14 +321,14 @@ func streamTSV[T any](w io.Writer, seq iter.Seq[T]) error {
 	return streamErr
 }
 
-func streamJSONL[T any](w io.Writer, seq iter.Seq[T]) error {
+func streamJSONL[T any](w io.Writer, seq iter.Seq[T], o *options) error {
 	var streamErr error
 	seq(func(item T) bool {
-		enc := json.NewEncoder(w)
-		if ind, ok := any(item).(Indented); ok {
-			enc.SetIndent("", ind.Indent())
+		if ok, err := writeFormatter(w, JSONL, item); ok || err != nil {
+			streamErr = err
+			return err == nil
 		}
-		if err := enc.Encode(item); err != nil {
+		if err := writeJSONLine(w, item, o); err != nil {
 			streamErr = err
 			return false
 		}
@@ -172,13 +340,11 @@ func streamJSONL[T any](w io.Writer, seq iter.Seq[T]) error {
 func streamPlain[T any](w io.Writer, seq iter.Seq[T]) error {
 	var streamErr error
 	seq(func(item T) bool {
-		var s string
-		if str, ok := any(item).(fmt.Stringer); ok {
-			s = str.String()
-		} else {
-			s = fmt.Sprintf("%v", item)
+		if ok, err := writeFormatter(w, Plain, item); ok || err != nil {
+			streamErr = err
+			return err == nil
 		}
-		if _, err := fmt.Fprintln(w, s); err != nil {
+		if _, err := fmt.Fprintln(w, stringOf(item)); err != nil {
 			streamErr = err
 			return false
 		}
@@ -187,14 +353,29 @@ func streamPlain[T any](w io.Writer, seq iter.Seq[T]) error {
 	return streamErr
 }
 
-func streamGoTemplate[T any](w io.Writer, tmplStr string, seq iter.Seq[T]) error {
-	var items []T
-	seq(func(item T) bool {
-		items = append(items, item)
-		return true
-	})
-	if len(items) == 0 {
-		return nil
+// streamRow returns the row of the item at index i for the row-based format
+// f, read from its Formatter output when it has one.
+func streamRow[T any](f Format, item T, i int, comma rune, o *options) ([]string, error) {
+	data, err := formatItem(f, item)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		return parseRow(f, data, comma, i)
+	}
+	if missing := o.missingFor(f, item); missing != nil {
+		return nil, newMissingInterfaceError[T](f, item, i, missing...)
+	}
+	return o.rowOf(item), nil
+}
+
+// writeFormatter writes item's Formatter output for f verbatim and reports
+// whether it had any.
+func writeFormatter(w io.Writer, f Format, item any) (bool, error) {
+	data, err := formatItem(f, item)
+	if err != nil || data == nil {
+		return false, err
 	}
-	return writeGoTemplate(w, tmplStr, items)
+	_, err = w.Write(data)
+	return true, err
 }
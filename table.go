@@ -3,164 +3,642 @@ package fmter
 import (
 	"fmt"
 	"io"
+	"slices"
+	"strconv"
 	"strings"
-
-	"github.com/mattn/go-runewidth"
+	"unicode"
 )
 
-type borderChars struct {
-	topLeft, topRight, bottomLeft, bottomRight string
-	horizontal, vertical                       string
-	topTee, bottomTee, leftTee, rightTee       string
-	cross                                      string
-}
-
-var borderSets = map[BorderStyle]borderChars{
-	BorderRounded: {
-		topLeft: "╭", topRight: "╮", bottomLeft: "╰", bottomRight: "╯",
-		horizontal: "─", vertical: "│",
-		topTee: "┬", bottomTee: "┴", leftTee: "├", rightTee: "┤",
-		cross: "┼",
-	},
-	BorderASCII: {
-		topLeft: "+", topRight: "+", bottomLeft: "+", bottomRight: "+",
-		horizontal: "-", vertical: "|",
-		topTee: "+", bottomTee: "+", leftTee: "+", rightTee: "+",
-		cross: "+",
-	},
-	BorderHeavy: {
-		topLeft: "┏", topRight: "┓", bottomLeft: "┗", bottomRight: "┛",
-		horizontal: "━", vertical: "┃",
-		topTee: "┳", bottomTee: "┻", leftTee: "┣", rightTee: "┫",
-		cross: "╋",
-	},
-	BorderDouble: {
-		topLeft: "╔", topRight: "╗", bottomLeft: "╚", bottomRight: "╝",
-		horizontal: "═", vertical: "║",
-		topTee: "╦", bottomTee: "╩", leftTee: "╠", rightTee: "╣",
-		cross: "╬",
-	},
-}
-
-func writeTable[T any](w io.Writer, items []T) error {
+func writeTable[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
-	first := any(items[0])
-	if _, ok := first.(Rower); !ok {
-		return fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, Table, items[0])
+	if err := checkItems(Table, items, o); err != nil {
+		return err
+	}
+	spec, err := newTableSpec(w, any(items[0]), o)
+	if err != nil {
+		return err
 	}
-
 	rows := make([][]string, len(items))
+	spans := make([][]int, len(items))
+	agg := newAggregator(spec.aggregates)
 	for i, item := range items {
-		rows[i] = any(item).(Rower).Row()
+		rows[i] = spec.row(item, i, o)
+		spans[i] = spec.rowSpans(item, len(rows[i]))
+		agg.add(rows[i])
+	}
+	spec.footer = agg.footer(spec.footer)
+	// Size the columns for the subtotal rows too.
+	sized := rows
+	if subtotals := subtotalRows(spec.subtotals, rows, groupRuns(items)); subtotals != nil {
+		sized = append(slices.Clip(rows), subtotals...)
+	}
+	tw := newTableWriter(w, spec, spec.widths(sized, spans))
+	if err := tw.begin(spec.title); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		if err := tw.row(row, items[i]); err != nil {
+			return err
+		}
 	}
+	return tw.end()
+}
 
-	var header []string
-	if h, ok := first.(Headed); ok {
-		header = h.Header()
+// tableSpec is the table metadata resolved once from the first item, with
+// column selection and row numbering already applied.
+type tableSpec struct {
+	cols        []int
+	numbered    bool
+	grouped     bool
+	groupHeader func(string) string // nil without group headers
+	subtotals   []Aggregate
+	groupCells  []string // header group titles, nil without header groups
+	groupSpans  []int
+	title       string
+	caption     string
+	header      []string
+	footer      []string
+	aggregates  []Aggregate
+	aligns      []Alignment
+	styles      []func(string) string
+	maxWidths   []int
+	wrapWidths  []int
+	fits        []Fit
+	bordered    bool
+	borders     BorderSet
+	pageSize    int
+	width       int // maximum table width, 0 for none
+	theme       Theme
+}
+
+func newTableSpec(w io.Writer, first any, o *options) (*tableSpec, error) {
+	cols, err := o.columnsFor(first)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &tableSpec{
+		cols:        cols,
+		title:       o.titleFor(first),
+		caption:     o.captionFor(first),
+		header:      pick(o.headerFor(first), cols),
+		aggregates:  pick(o.aggregatesFor(first), cols),
+		subtotals:   pick(o.subtotalsFor(first), cols),
+		groupHeader: o.groupHeaderFor(first),
+		aligns:      pick(o.alignsFor(first), cols),
+		maxWidths:   pick(o.maxWidthsFor(first), cols),
+		pageSize:    o.pageSizeFor(first),
+		width:       o.tableWidthFor(w),
+		theme:       o.themeFor(w, first),
+	}
+	s.footer = footerOf(first, cols)
+	s.borders, s.bordered = o.borderSetFor(first)
+	if st, ok := first.(Styled); ok {
+		s.styles = pick(st.Styles(), cols)
 	}
+	if wr, ok := first.(Wrapped); ok {
+		s.wrapWidths = pick(wr.WrapWidths(), cols)
+	}
+	if ft, ok := first.(Fitted); ok {
+		s.fits = pick(ft.Fits(), cols)
+	}
+	_, s.grouped = first.(Grouped)
 
-	var title string
-	if t, ok := first.(Titled); ok {
-		title = t.Title()
+	// Apply row numbering by prepending a column.
+	if n, ok := first.(Numbered); ok {
+		s.numbered = true
+		if len(s.header) > 0 {
+			s.header = append([]string{n.NumberHeader()}, s.header...)
+		}
+		if len(s.footer) > 0 {
+			s.footer = append([]string{""}, s.footer...)
+		}
+		if len(s.aggregates) > 0 {
+			s.aggregates = append([]Aggregate{AggregateNone}, s.aggregates...)
+		}
+		if len(s.subtotals) > 0 {
+			s.subtotals = append([]Aggregate{AggregateNone}, s.subtotals...)
+		}
+		s.aligns = append([]Alignment{AlignRight}, s.aligns...)
+		s.styles = append([]func(string) string{nil}, s.styles...)
+		if len(s.wrapWidths) > 0 {
+			s.wrapWidths = append([]int{0}, s.wrapWidths...)
+		}
+		if len(s.maxWidths) > 0 {
+			s.maxWidths = append([]int{0}, s.maxWidths...)
+		}
+		if len(s.fits) > 0 {
+			s.fits = append([]Fit{{}}, s.fits...)
+		}
+	}
+	if groups := o.headerGroupsFor(first); groups != nil && len(s.header) > 0 {
+		s.groupCells, s.groupSpans = groupRow(groups, len(s.header), s.column)
 	}
+	return s, nil
+}
 
-	border := BorderRounded
-	if b, ok := first.(Bordered); ok {
-		border = b.Border()
+// row returns the cells for item, the i-th row (zero-based) of the table.
+func (s *tableSpec) row(item any, i int, o *options) []string {
+	return s.cells(o.rowAt(i, item), i)
+}
+
+// cells applies column selection and numbering to row, the row of the item
+// at index i.
+func (s *tableSpec) cells(row []string, i int) []string {
+	row = pick(row, s.cols)
+	if s.numbered {
+		row = append([]string{strconv.Itoa(i + 1)}, row...)
 	}
+	return row
+}
 
-	var aligns []Alignment
-	if a, ok := first.(Aligned); ok {
-		aligns = a.Alignments()
+// rowSpans returns the spans of item's n displayed cells, or nil when none
+// span columns.
+func (s *tableSpec) rowSpans(item any, n int) []int {
+	return rowSpans(item, n, s.column)
+}
+
+func (s *tableSpec) group(item any) string {
+	if g, ok := item.(Grouped); ok && s.grouped {
+		return g.Group()
 	}
+	return ""
+}
 
-	var footer []string
-	if f, ok := first.(Footered); ok {
-		footer = f.Footer()
+// widths sizes each column to fit the header, rows, and footer, capped by
+// any [Truncated] maximum. Cells spanning columns, as given by spans, only
+// widen the last column they cover, and only when they don't fit.
+func (s *tableSpec) widths(rows [][]string, spans [][]int) []int {
+	numCols := colCount(s.header, rows, s.footer)
+	sized := rows
+	if slices.ContainsFunc(spans, func(sp []int) bool { return sp != nil }) {
+		sized = make([][]string, len(rows))
+		for i, row := range rows {
+			sized[i] = unspanned(row, at(spans, i))
+		}
+	}
+	widths := computeWidths(numCols, s.header, sized, s.footer)
+	for i, max := range s.maxWidths {
+		if i < numCols && max > 0 && widths[i] > max {
+			widths[i] = max
+		}
 	}
+	for i, row := range rows {
+		if sp := at(spans, i); sp != nil {
+			s.widen(widths, row, sp)
+		}
+	}
+	if s.groupCells != nil {
+		s.widen(widths, s.groupCells, s.groupSpans)
+	}
+	return widths
+}
 
-	var numHdr string
-	numbered := false
-	if n, ok := first.(Numbered); ok {
-		numbered = true
-		numHdr = n.NumberHeader()
+// unspanned returns row with the cells that span columns blanked.
+func unspanned(row []string, spans []int) []string {
+	if spans == nil {
+		return row
 	}
+	out := make([]string, len(row))
+	for i, cell := range row {
+		if at(spans, i) == 1 {
+			out[i] = cell
+		}
+	}
+	return out
+}
 
-	var caption string
-	if c, ok := first.(Captioned); ok {
-		caption = c.Caption()
+// widen grows the last column covered by each of cells, laid out by spans
+// (nil when each covers one column), that is wider than its columns.
+func (s *tableSpec) widen(widths []int, cells []string, spans []int) {
+	for i := range widths {
+		n := 1
+		if i < len(spans) {
+			n = min(spans[i], len(widths)-i)
+		}
+		if n == 0 {
+			continue
+		}
+		have := s.gap() * (n - 1)
+		for _, w := range widths[i : i+n] {
+			have += w
+		}
+		if need := cellWidth(at(cells, i)); need > have {
+			widths[i+n-1] += need - have
+		}
 	}
+}
+
+// gap returns the width between the text of adjacent columns.
+func (s *tableSpec) gap() int {
+	if s.bordered && !s.borders.NoColumns {
+		return 3 // " │ "
+	}
+	return 2
+}
+
+// tableWriter draws a table one row at a time, so rows need not be held in
+// memory once the column widths are known.
+type tableWriter struct {
+	w       io.Writer
+	spec    *tableSpec
+	bc      BorderSet
+	natural []int // widths before fitting to spec.width
+	widths  []int
+	wraps   []int
+	aligns  []Alignment
+	styles  []func(string) string
+	vert    string // themed vertical border
+	rows    int    // data rows drawn since the header
+	group   string // group of the previous row
+	grouped bool   // whether a previous row has set group
+	sub     *aggregator
+	above   []bool     // column boundaries of the last line drawn, nil at the top
+	pending *tableRule // rule waiting for the line below it
+}
+
+func newTableWriter(w io.Writer, spec *tableSpec, widths []int) *tableWriter {
+	tw := &tableWriter{w: w, spec: spec, bc: spec.borders}
+	tw.vert = paint(spec.theme.Border, tw.bc.Vertical)
+	tw.setWidths(widths)
+	return tw
+}
+
+func (tw *tableWriter) setWidths(widths []int) {
+	tw.natural = widths
+	tw.widths, tw.wraps = tw.spec.fit(widths)
+	tw.aligns = extendAligns(tw.spec.aligns, len(widths))
+	tw.styles = extendStyles(tw.spec.styles, len(widths))
+}
 
-	var styles []func(string) string
-	if s, ok := first.(Styled); ok {
-		styles = s.Styles()
+func (tw *tableWriter) plain() bool { return !tw.spec.bordered }
+
+// framed reports whether the table has an outer frame.
+func (tw *tableWriter) framed() bool { return tw.spec.bordered && !tw.bc.NoFrame }
+
+// begin draws the title, top border, and header.
+func (tw *tableWriter) begin(title string) error {
+	if tw.framed() {
+		tw.hline(tw.bc.TopLeft, tw.bc.TopRight)
 	}
+	if title != "" && !tw.plain() {
+		if err := tw.span(paint(tw.spec.theme.Title, alignCell(title, tw.spanWidth(), AlignCenter))); err != nil {
+			return err
+		}
+		if tw.framed() {
+			tw.hline(tw.bc.LeftTee, tw.bc.RightTee)
+		}
+	}
+	return tw.header()
+}
 
-	var groups []string
-	if _, ok := first.(Grouped); ok {
-		groups = make([]string, len(items))
-		for i, item := range items {
-			groups[i] = any(item).(Grouped).Group()
+func (tw *tableWriter) header() error {
+	if len(tw.spec.header) == 0 {
+		return nil
+	}
+	if tw.spec.groupCells != nil {
+		if err := tw.headerGroups(); err != nil {
+			return err
 		}
 	}
+	if err := tw.drawPart(tw.spec.header, tw.spec.theme.Header); err != nil {
+		return err
+	}
+	return tw.separator(tw.spec.theme.Border)
+}
 
-	var wrapWidths []int
-	if wr, ok := first.(Wrapped); ok {
-		wrapWidths = wr.WrapWidths()
+// headerGroups draws the row of header group titles, each centered over the
+// columns of its group.
+func (tw *tableWriter) headerGroups() error {
+	line := tw.line(tw.spec.groupCells, tw.spec.groupSpans, tw.partStyles(tw.spec.theme.Header))
+	line.aligns = slices.Repeat([]Alignment{AlignCenter}, len(line.cells))
+	if err := tw.drawLine(line); err != nil {
+		return err
+	}
+	if !tw.plain() {
+		tw.hline(tw.bc.LeftTee, tw.bc.RightTee)
 	}
+	return nil
+}
 
-	pageSize := 0
-	if p, ok := first.(Paged); ok {
-		pageSize = p.PageSize()
+// separator draws a horizontal rule between rows in style.
+func (tw *tableWriter) separator(style func(string) string) error {
+	if tw.plain() {
+		return writePlainSep(tw.w, tw.widths, style)
+	}
+	// A rule already waiting, such as the line between two groups before
+	// a repeated header, is drawn first, meeting the columns on both sides.
+	if err := tw.flush(tw.above); err != nil {
+		return err
 	}
+	tw.rule(tw.bc.LeftTee, tw.bc.RightTee, style)
+	return nil
+}
 
-	// Apply row numbering by prepending a column.
-	if numbered {
-		if len(header) > 0 {
-			header = append([]string{numHdr}, header...)
+// hline draws a horizontal border ending in the given corner or tee
+// characters, in the theme's border style.
+func (tw *tableWriter) hline(left, right string) {
+	tw.rule(left, right, tw.spec.theme.Border)
+}
+
+// tableRule is a horizontal line waiting for the line below it, which
+// decides its column junctions.
+type tableRule struct {
+	left, right string
+	style       func(string) string
+}
+
+// rule draws a horizontal line in style, with left and right at the frame.
+// The line is drawn by the next flush, once the columns it joins are known.
+func (tw *tableWriter) rule(left, right string, style func(string) string) {
+	tw.pending = &tableRule{left: left, right: right, style: style}
+}
+
+// flush draws the pending rule above a line whose column boundaries are
+// below, or nil for the bottom of the table. A junction joins the vertical
+// lines above and below it: a cross where both have one, a tee where one
+// does, and a plain line where neither does.
+func (tw *tableWriter) flush(below []bool) error {
+	r, above := tw.pending, tw.above
+	tw.pending, tw.above = nil, below
+	if r == nil {
+		return nil
+	}
+	var sb strings.Builder
+	if tw.framed() {
+		sb.WriteString(r.left)
+	}
+	for i := range tw.widths {
+		sb.WriteString(strings.Repeat(tw.bc.Horizontal, tw.paddedWidth(i)))
+		if i == len(tw.widths)-1 || tw.bc.NoColumns {
+			continue
 		}
-		for i, row := range rows {
-			rows[i] = append([]string{fmt.Sprintf("%d", i+1)}, row...)
+		switch up, down := at(above, i), at(below, i); {
+		case up && down:
+			sb.WriteString(tw.bc.Cross)
+		case up:
+			sb.WriteString(tw.bc.BottomTee)
+		case down:
+			sb.WriteString(tw.bc.TopTee)
+		default:
+			sb.WriteString(tw.bc.Horizontal)
 		}
-		if len(footer) > 0 {
-			footer = append([]string{""}, footer...)
+	}
+	if tw.framed() {
+		sb.WriteString(r.right)
+	}
+	_, err := fmt.Fprintln(tw.w, paint(r.style, sb.String()))
+	return err
+}
+
+// paddedWidth returns the width of column i with the space on either side
+// of it, which an unframed table drops at its outer edges.
+func (tw *tableWriter) paddedWidth(i int) int {
+	width := tw.widths[i] + 2
+	if !tw.framed() {
+		if i == 0 {
+			width--
 		}
-		aligns = append([]Alignment{AlignRight}, aligns...)
-		styles = append([]func(string) string{nil}, styles...)
-		if len(wrapWidths) > 0 {
-			wrapWidths = append([]int{0}, wrapWidths...)
+		if i == len(tw.widths)-1 {
+			width--
 		}
 	}
+	return width
+}
 
-	numCols := colCount(header, rows, footer)
-	widths := computeWidths(numCols, header, rows, footer)
+// spanWidth returns the width of a row spanning every column, such as the
+// title, inside the frame and its padding.
+func (tw *tableWriter) spanWidth() int {
+	n := tableInnerWidth(tw.widths, tw.bc)
+	if tw.framed() {
+		n -= 2
+	}
+	return n
+}
 
-	// Apply max column widths for truncation.
-	if tr, ok := first.(Truncated); ok {
-		for i, max := range tr.MaxWidths() {
-			if i < numCols && max > 0 && widths[i] > max {
-				widths[i] = max
-			}
+// span draws text, already sized to spanWidth, as a row spanning every
+// column.
+func (tw *tableWriter) span(text string) error {
+	if err := tw.flush(make([]bool, max(len(tw.widths)-1, 0))); err != nil {
+		return err
+	}
+	if !tw.framed() {
+		_, err := fmt.Fprintln(tw.w, strings.TrimRight(text, " "))
+		return err
+	}
+	_, err := fmt.Fprintf(tw.w, "%s %s %s\n", tw.vert, text, tw.vert)
+	return err
+}
+
+// partStyles returns the styles of a header or footer row, whose cells take
+// the theme's style for that part in place of the column styles when it
+// sets one.
+func (tw *tableWriter) partStyles(style func(string) string) []func(string) string {
+	if style == nil {
+		return tw.styles
+	}
+	styles := make([]func(string) string, len(tw.widths))
+	for i := range styles {
+		styles[i] = style
+	}
+	return styles
+}
+
+// drawPart draws a header or footer row.
+func (tw *tableWriter) drawPart(cells []string, style func(string) string) error {
+	return tw.drawWith(cells, nil, tw.partStyles(style))
+}
+
+func (tw *tableWriter) drawWith(cells []string, spans []int, styles []func(string) string) error {
+	return tw.drawLine(tw.line(cells, spans, styles))
+}
+
+func (tw *tableWriter) drawLine(line tableLine) error {
+	if tw.plain() {
+		return writePlainRow(tw.w, line.cells, line.widths, line.aligns, line.styles, line.wraps)
+	}
+	if err := tw.flush(line.bounds); err != nil {
+		return err
+	}
+	return tw.drawBorderedRow(line)
+}
+
+// tableLine is a row laid out over the table's columns, with each cell that
+// spans columns merged into one as wide as them.
+type tableLine struct {
+	cells  []string
+	widths []int
+	aligns []Alignment
+	styles []func(string) string
+	wraps  []int
+	bounds []bool // whether a vertical line follows each column but the last
+}
+
+// line lays out cells over the columns, as given by spans, or one cell per
+// column when spans is nil. Cells spanning columns are not wrapped.
+func (tw *tableWriter) line(cells []string, spans []int, styles []func(string) string) tableLine {
+	n := len(tw.widths)
+	bounds := make([]bool, max(n-1, 0))
+	for i := range bounds {
+		bounds[i] = i+1 >= len(spans) || spans[i+1] != 0
+	}
+	if spans == nil {
+		return tableLine{cells: cells, widths: tw.widths, aligns: tw.aligns, styles: styles, wraps: tw.wraps, bounds: bounds}
+	}
+	l := tableLine{bounds: bounds}
+	for i := 0; i < n; i++ {
+		span := 1
+		if i < len(spans) {
+			span = min(spans[i], n-i)
 		}
+		if span == 0 {
+			continue
+		}
+		width, wrap := tw.spec.gap()*(span-1), 0
+		for _, w := range tw.widths[i : i+span] {
+			width += w
+		}
+		if span == 1 {
+			wrap = at(tw.wraps, i)
+		}
+		l.cells = append(l.cells, at(cells, i))
+		l.widths = append(l.widths, width)
+		l.aligns = append(l.aligns, tw.aligns[i])
+		l.styles = append(l.styles, styles[i])
+		l.wraps = append(l.wraps, wrap)
 	}
+	return l
+}
 
-	aligns = extendAligns(aligns, numCols)
-	styles = extendStyles(styles, numCols)
+// row draws the data row of item. When its group changes, the previous
+// group's subtotal and a separator or group header come first; at each page
+// boundary, a repeated header; otherwise, a row line when the border set
+// draws them.
+func (tw *tableWriter) row(cells []string, item any) error {
+	group := tw.spec.group(item)
+	changed := tw.grouped && tw.spec.grouped && group != tw.group
+	if changed {
+		if err := tw.subtotal(); err != nil {
+			return err
+		}
+	}
+	paged := tw.rows > 0 && tw.spec.pageSize > 0 && tw.rows%tw.spec.pageSize == 0 && len(tw.spec.header) > 0
+	if tw.rows > 0 && changed && (tw.spec.groupHeader == nil || !paged) {
+		if err := tw.groupSeparator(); err != nil {
+			return err
+		}
+	}
+	if tw.rows > 0 && !changed && !paged && tw.bc.RowLines {
+		tw.hline(tw.bc.LeftTee, tw.bc.RightTee)
+	}
+	if paged {
+		if err := tw.separator(tw.spec.theme.Border); err != nil {
+			return err
+		}
+		if err := tw.header(); err != nil {
+			return err
+		}
+	}
+	if tw.spec.groupHeader != nil && (tw.rows == 0 || changed || paged) {
+		if err := tw.groupHeader(group); err != nil {
+			return err
+		}
+	}
+	tw.rows++
+	tw.group, tw.grouped = group, true
+	if tw.sub == nil {
+		tw.sub = newAggregator(tw.spec.subtotals)
+	}
+	tw.sub.add(cells)
+	return tw.drawWith(cells, tw.spec.rowSpans(item, len(tw.widths)), tw.spec.rowStyles(item, cells, tw.styles))
+}
 
-	var err error
-	if border == BorderNone {
-		err = renderPlainTable(w, header, rows, footer, widths, aligns, styles, groups, wrapWidths, pageSize)
-	} else {
-		err = renderBorderedTable(w, title, header, rows, footer, widths, aligns, border, styles, groups, wrapWidths, pageSize)
+// groupSeparator draws the line between two groups of rows.
+func (tw *tableWriter) groupSeparator() error {
+	style := tw.spec.theme.Separator
+	if style == nil {
+		style = tw.spec.theme.Border
 	}
-	if err != nil {
+	if tw.spec.groupHeader != nil && tw.plain() {
+		// The group header sets the group off; a blank line suffices.
+		_, err := fmt.Fprintln(tw.w)
 		return err
 	}
+	return tw.separator(style)
+}
 
-	if caption != "" {
-		if _, err := fmt.Fprintln(w, caption); err != nil {
+// groupHeader draws the full-width row naming group, in the title style.
+func (tw *tableWriter) groupHeader(group string) error {
+	label := tw.spec.groupHeader(group)
+	if tw.plain() {
+		_, err := fmt.Fprintln(tw.w, paint(tw.spec.theme.Title, label))
+		return err
+	}
+	if err := tw.span(paint(tw.spec.theme.Title, formatTableCell(label, tw.spanWidth(), AlignLeft))); err != nil {
+		return err
+	}
+	tw.hline(tw.bc.LeftTee, tw.bc.RightTee)
+	return nil
+}
+
+// subtotal draws the subtotal row of the group that just ended, if any.
+func (tw *tableWriter) subtotal() error {
+	sub := tw.sub
+	tw.sub = nil
+	if sub == nil {
+		return nil
+	}
+	if err := tw.separator(tw.spec.theme.Border); err != nil {
+		return err
+	}
+	return tw.drawPart(sub.footer(nil), tw.spec.theme.Footer)
+}
+
+// restart ends the current table and begins a new one with the given widths
+// and a repeated header.
+func (tw *tableWriter) restart(widths []int) error {
+	if !tw.framed() {
+		if len(tw.spec.header) > 0 {
+			if _, err := fmt.Fprintln(tw.w); err != nil {
+				return err
+			}
+		}
+		tw.above = nil
+	} else if err := tw.bottom(); err != nil {
+		return err
+	}
+	tw.setWidths(widths)
+	tw.rows = 0
+	return tw.begin("")
+}
+
+func (tw *tableWriter) bottom() error {
+	tw.hline(tw.bc.BottomLeft, tw.bc.BottomRight)
+	return tw.flush(nil)
+}
+
+// end draws the last subtotal, footer, bottom border, and caption.
+func (tw *tableWriter) end() error {
+	if err := tw.subtotal(); err != nil {
+		return err
+	}
+	if len(tw.spec.footer) > 0 {
+		if err := tw.separator(tw.spec.theme.Border); err != nil {
+			return err
+		}
+		if err := tw.drawPart(tw.spec.footer, tw.spec.theme.Footer); err != nil {
+			return err
+		}
+	}
+	if tw.framed() {
+		if err := tw.bottom(); err != nil {
+			return err
+		}
+	}
+	if tw.spec.caption != "" {
+		if _, err := fmt.Fprintln(tw.w, paint(tw.spec.theme.Caption, tw.spec.caption)); err != nil {
 			return err
 		}
 	}
@@ -183,19 +661,19 @@ func colCount(header []string, rows [][]string, footer []string) int {
 func computeWidths(numCols int, header []string, rows [][]string, footer []string) []int {
 	widths := make([]int, numCols)
 	for i, h := range header {
-		if w := runewidth.StringWidth(h); w > widths[i] {
+		if w := cellWidth(h); w > widths[i] {
 			widths[i] = w
 		}
 	}
 	for _, row := range rows {
 		for i, cell := range row {
-			if w := runewidth.StringWidth(cell); i < numCols && w > widths[i] {
+			if w := cellWidth(cell); i < numCols && w > widths[i] {
 				widths[i] = w
 			}
 		}
 	}
 	for i, cell := range footer {
-		if w := runewidth.StringWidth(cell); i < numCols && w > widths[i] {
+		if w := cellWidth(cell); i < numCols && w > widths[i] {
 			widths[i] = w
 		}
 	}
@@ -222,26 +700,97 @@ func extendStyles(styles []func(string) string, numCols int) []func(string) stri
 
 // --- Cell wrapping ---
 
+// cellLines splits a cell at embedded newlines, so that multi-line values
+// render as multi-line cells rather than breaking the table.
+func cellLines(s string) []string {
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
+
+// cellWidth returns the display width of the widest line of a cell.
+func cellWidth(s string) int {
+	if !strings.Contains(s, "\n") {
+		return displayWidth(s)
+	}
+	w := 0
+	for _, line := range cellLines(s) {
+		w = max(w, displayWidth(line))
+	}
+	return w
+}
+
+// wrapCell splits s into lines at its embedded newlines and, when width is
+// positive, soft-wraps each line at spaces to fit width. Words wider than
+// width are broken wherever they reach it. Styles spanning a line break are
+// closed and reopened around it.
 func wrapCell(s string, width int) []string {
-	if width <= 0 || runewidth.StringWidth(s) <= width {
+	var lines []string
+	for _, line := range cellLines(s) {
+		lines = append(lines, wrapLine(line, width)...)
+	}
+	if strings.Contains(s, "\x1b") {
+		lines = carryStyles(lines)
+	}
+	return lines
+}
+
+func wrapLine(s string, width int) []string {
+	if width <= 0 || displayWidth(s) <= width {
 		return []string{s}
 	}
 	var lines []string
-	for len(s) > 0 {
-		line := runewidth.Truncate(s, width, "")
-		lineWidth := runewidth.StringWidth(line)
-		if lineWidth == 0 && len(s) > 0 {
-			// Safety: advance at least one rune to avoid infinite loop.
-			r := []rune(s)
-			line = string(r[0])
-			lineWidth = runewidth.RuneWidth(r[0])
+	line, lineWidth := "", 0
+	gaps, words := splitWords(s)
+	for i, word := range words {
+		// Spaces between words are kept as written, except where the line
+		// breaks. The first word keeps the line's indentation.
+		gap := gaps[i]
+		if i == 0 {
+			word, gap = gap+word, ""
+		}
+		wordWidth, gapWidth := displayWidth(word), displayWidth(gap)
+		if line != "" && lineWidth+gapWidth+wordWidth <= width {
+			line += gap + word
+			lineWidth += gapWidth + wordWidth
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		for wordWidth > width {
+			part, rest := cutWidth(word, width)
+			if part == "" {
+				// Safety: the next character is wider than width (at most
+				// 2 columns); advance past it to avoid an infinite loop.
+				part, rest = cutWidth(word, 2)
+			}
+			lines = append(lines, part)
+			word, wordWidth = rest, displayWidth(rest)
 		}
+		line, lineWidth = word, wordWidth
+	}
+	if line != "" || len(lines) == 0 {
 		lines = append(lines, line)
-		s = s[len(line):]
 	}
 	return lines
 }
 
+// splitWords splits s at runs of spaces, returning each word and the run
+// before it. Trailing spaces are dropped.
+func splitWords(s string) (gaps, words []string) {
+	for {
+		start := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			return gaps, words
+		}
+		end := strings.IndexFunc(s[start:], unicode.IsSpace)
+		if end < 0 {
+			end = len(s) - start
+		}
+		gaps, words = append(gaps, s[:start]), append(words, s[start:start+end])
+		s = s[start+end:]
+	}
+}
+
 func wrapRow(cells []string, widths []int, wrapWidths []int) [][]string {
 	wrapped := make([][]string, len(widths))
 	for i, width := range widths {
@@ -253,11 +802,11 @@ func wrapRow(cells []string, widths []int, wrapWidths []int) [][]string {
 		if i < len(wrapWidths) {
 			ww = wrapWidths[i]
 		}
-		if ww > 0 && ww < width {
+		if ww > 0 && ww <= width {
 			// Use wrap width for wrapping but column width for formatting.
 			wrapped[i] = wrapCell(cell, ww)
 		} else {
-			wrapped[i] = []string{cell}
+			wrapped[i] = cellLines(cell)
 		}
 	}
 	return wrapped
@@ -275,260 +824,115 @@ func maxLines(wrapped [][]string) int {
 
 // --- Plain table (BorderNone) ---
 
-func renderPlainTable(w io.Writer, header []string, rows [][]string, footer []string, widths []int, aligns []Alignment, styles []func(string) string, groups []string, wrapWidths []int, pageSize int) error {
-	if len(header) > 0 {
-		if err := writePlainRow(w, header, widths, aligns, styles, wrapWidths); err != nil {
-			return err
-		}
-		if err := writePlainSep(w, widths); err != nil {
-			return err
-		}
-	}
-	for i, row := range rows {
-		if len(groups) > 0 && i > 0 && groups[i] != groups[i-1] {
-			if err := writePlainSep(w, widths); err != nil {
-				return err
-			}
-		}
-		if pageSize > 0 && len(header) > 0 && i > 0 && i%pageSize == 0 {
-			if err := writePlainSep(w, widths); err != nil {
-				return err
-			}
-			if err := writePlainRow(w, header, widths, aligns, styles, wrapWidths); err != nil {
-				return err
-			}
-			if err := writePlainSep(w, widths); err != nil {
-				return err
-			}
-		}
-		if err := writePlainRow(w, row, widths, aligns, styles, wrapWidths); err != nil {
-			return err
-		}
-	}
-	if len(footer) > 0 {
-		if err := writePlainSep(w, widths); err != nil {
-			return err
-		}
-		if err := writePlainRow(w, footer, widths, aligns, styles, wrapWidths); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func writePlainSep(w io.Writer, widths []int) error {
+func writePlainSep(w io.Writer, widths []int, style func(string) string) error {
 	sep := make([]string, len(widths))
 	for i, width := range widths {
 		sep[i] = strings.Repeat("-", width)
 	}
-	_, err := fmt.Fprintln(w, strings.Join(sep, "  "))
+	_, err := fmt.Fprintln(w, paint(style, strings.Join(sep, "  ")))
 	return err
 }
 
 func writePlainRow(w io.Writer, cells []string, widths []int, aligns []Alignment, styles []func(string) string, wrapWidths []int) error {
-	if len(wrapWidths) > 0 {
-		wrapped := wrapRow(cells, widths, wrapWidths)
-		nLines := maxLines(wrapped)
-		for line := range nLines {
-			parts := make([]string, len(widths))
-			for i, width := range widths {
-				cell := ""
-				if line < len(wrapped[i]) {
-					cell = wrapped[i][line]
-				}
-				formatted := formatTableCell(cell, width, aligns[i])
-				if styles[i] != nil {
-					formatted = styles[i](formatted)
-				}
-				parts[i] = formatted
+	wrapped := wrapRow(cells, widths, wrapWidths)
+	nLines := maxLines(wrapped)
+	for line := range nLines {
+		parts := make([]string, len(widths))
+		for i, width := range widths {
+			cell := ""
+			if line < len(wrapped[i]) {
+				cell = wrapped[i][line]
 			}
-			text := strings.TrimRight(strings.Join(parts, "  "), " ")
-			if _, err := fmt.Fprintln(w, text); err != nil {
-				return err
+			formatted := formatTableCell(cell, width, aligns[i])
+			if styles[i] != nil {
+				formatted = styles[i](formatted)
 			}
+			parts[i] = formatted
 		}
-		return nil
-	}
-	parts := make([]string, len(widths))
-	for i, width := range widths {
-		cell := ""
-		if i < len(cells) {
-			cell = cells[i]
-		}
-		formatted := formatTableCell(cell, width, aligns[i])
-		if styles[i] != nil {
-			formatted = styles[i](formatted)
+		text := strings.TrimRight(strings.Join(parts, "  "), " ")
+		if _, err := fmt.Fprintln(w, text); err != nil {
+			return err
 		}
-		parts[i] = formatted
 	}
-	line := strings.TrimRight(strings.Join(parts, "  "), " ")
-	_, err := fmt.Fprintln(w, line)
-	return err
+	return nil
 }
 
 // --- Bordered table ---
 
-func renderBorderedTable(w io.Writer, title string, header []string, rows [][]string, footer []string, widths []int, aligns []Alignment, style BorderStyle, styles []func(string) string, groups []string, wrapWidths []int, pageSize int) error {
-	bc := borderSets[style]
-
-	if title != "" {
-		// Full-width top border (no column separators).
-		if err := drawHLine(w, widths, bc.topLeft, bc.horizontal, bc.horizontal, bc.topRight); err != nil {
-			return err
-		}
-		inner := tableInnerWidth(widths) - 2 // subtract 1-space padding on each side
-		padded := alignCell(title, inner, AlignCenter)
-		if _, err := fmt.Fprintf(w, "%s %s %s\n", bc.vertical, padded, bc.vertical); err != nil {
-			return err
-		}
-		// Transition to columns.
-		if err := drawHLine(w, widths, bc.leftTee, bc.horizontal, bc.topTee, bc.rightTee); err != nil {
-			return err
-		}
-	} else {
-		if err := drawHLine(w, widths, bc.topLeft, bc.horizontal, bc.topTee, bc.topRight); err != nil {
-			return err
-		}
-	}
-
-	if len(header) > 0 {
-		if err := drawBorderedRow(w, header, widths, aligns, bc.vertical, styles, wrapWidths); err != nil {
-			return err
-		}
-		if err := drawHLine(w, widths, bc.leftTee, bc.horizontal, bc.cross, bc.rightTee); err != nil {
-			return err
-		}
-	}
-
-	for i, row := range rows {
-		if len(groups) > 0 && i > 0 && groups[i] != groups[i-1] {
-			if err := drawHLine(w, widths, bc.leftTee, bc.horizontal, bc.cross, bc.rightTee); err != nil {
-				return err
-			}
-		}
-		if pageSize > 0 && len(header) > 0 && i > 0 && i%pageSize == 0 {
-			if err := drawHLine(w, widths, bc.leftTee, bc.horizontal, bc.cross, bc.rightTee); err != nil {
-				return err
-			}
-			if err := drawBorderedRow(w, header, widths, aligns, bc.vertical, styles, wrapWidths); err != nil {
-				return err
-			}
-			if err := drawHLine(w, widths, bc.leftTee, bc.horizontal, bc.cross, bc.rightTee); err != nil {
-				return err
-			}
-		}
-		if err := drawBorderedRow(w, row, widths, aligns, bc.vertical, styles, wrapWidths); err != nil {
-			return err
-		}
-	}
-
-	if len(footer) > 0 {
-		if err := drawHLine(w, widths, bc.leftTee, bc.horizontal, bc.cross, bc.rightTee); err != nil {
-			return err
-		}
-		if err := drawBorderedRow(w, footer, widths, aligns, bc.vertical, styles, wrapWidths); err != nil {
-			return err
-		}
-	}
-
-	return drawHLine(w, widths, bc.bottomLeft, bc.horizontal, bc.bottomTee, bc.bottomRight)
-}
-
 // tableInnerWidth returns the total character width between the outer vertical
 // borders of a bordered table. Each cell contributes its width plus 2 (one
 // space of padding on each side), and cells are separated by a single vertical
-// border character.
-func tableInnerWidth(widths []int) int {
+// border character unless bs omits them. Without a frame, the padding at the
+// outer edges is dropped too.
+func tableInnerWidth(widths []int, bs BorderSet) int {
 	n := 0
 	for _, w := range widths {
 		n += w + 2
 	}
-	if len(widths) > 1 {
+	if len(widths) > 1 && !bs.NoColumns {
 		n += len(widths) - 1
 	}
-	return n
-}
-
-func drawHLine(w io.Writer, widths []int, left, fill, mid, right string) error {
-	var sb strings.Builder
-	sb.WriteString(left)
-	for i, width := range widths {
-		sb.WriteString(strings.Repeat(fill, width+2))
-		if i < len(widths)-1 {
-			sb.WriteString(mid)
-		}
+	if bs.NoFrame {
+		n -= 2
 	}
-	sb.WriteString(right)
-	_, err := fmt.Fprintln(w, sb.String())
-	return err
+	return n
 }
 
-func drawBorderedRow(w io.Writer, cells []string, widths []int, aligns []Alignment, vert string, styles []func(string) string, wrapWidths []int) error {
-	if len(wrapWidths) > 0 {
-		wrapped := wrapRow(cells, widths, wrapWidths)
-		nLines := maxLines(wrapped)
-		for line := range nLines {
-			var sb strings.Builder
-			sb.WriteString(vert)
-			for i, width := range widths {
-				cell := ""
-				if line < len(wrapped[i]) {
-					cell = wrapped[i][line]
-				}
+func (tw *tableWriter) drawBorderedRow(l tableLine) error {
+	wrapped := wrapRow(l.cells, l.widths, l.wraps)
+	nLines := maxLines(wrapped)
+	last := len(l.widths) - 1
+	for line := range nLines {
+		var sb strings.Builder
+		if tw.framed() {
+			sb.WriteString(tw.vert)
+		}
+		for i, width := range l.widths {
+			cell := ""
+			if line < len(wrapped[i]) {
+				cell = wrapped[i][line]
+			}
+			if i > 0 || tw.framed() {
 				sb.WriteString(" ")
-				formatted := formatTableCell(cell, width, aligns[i])
-				if styles[i] != nil {
-					formatted = styles[i](formatted)
-				}
-				sb.WriteString(formatted)
+			}
+			formatted := formatTableCell(cell, width, l.aligns[i])
+			if l.styles[i] != nil {
+				formatted = l.styles[i](formatted)
+			}
+			sb.WriteString(formatted)
+			if i < last || tw.framed() {
 				sb.WriteString(" ")
-				if i < len(widths)-1 {
-					sb.WriteString(vert)
-				}
 			}
-			sb.WriteString(vert)
-			if _, err := fmt.Fprintln(w, sb.String()); err != nil {
-				return err
+			if i < last && !tw.bc.NoColumns {
+				sb.WriteString(tw.vert)
 			}
 		}
-		return nil
-	}
-	var sb strings.Builder
-	sb.WriteString(vert)
-	for i, width := range widths {
-		cell := ""
-		if i < len(cells) {
-			cell = cells[i]
-		}
-		sb.WriteString(" ")
-		formatted := formatTableCell(cell, width, aligns[i])
-		if styles[i] != nil {
-			formatted = styles[i](formatted)
+		text := sb.String()
+		if tw.framed() {
+			text += tw.vert
+		} else {
+			text = strings.TrimRight(text, " ")
 		}
-		sb.WriteString(formatted)
-		sb.WriteString(" ")
-		if i < len(widths)-1 {
-			sb.WriteString(vert)
+		if _, err := fmt.Fprintln(tw.w, text); err != nil {
+			return err
 		}
 	}
-	sb.WriteString(vert)
-	_, err := fmt.Fprintln(w, sb.String())
-	return err
+	return nil
 }
 
 func formatTableCell(s string, width int, align Alignment) string {
-	if width > 0 && runewidth.StringWidth(s) > width {
+	if width > 0 {
 		if width <= 3 {
-			s = runewidth.Truncate(s, width, "")
+			s = truncateWidth(s, width, "")
 		} else {
-			s = runewidth.Truncate(s, width, "...")
+			s = truncateWidth(s, width, "...")
 		}
 	}
 	return alignCell(s, width, align)
 }
 
 func alignCell(s string, width int, align Alignment) string {
-	pad := width - runewidth.StringWidth(s)
+	pad := width - displayWidth(s)
 	if pad <= 0 {
 		return s
 	}
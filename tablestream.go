@@ -0,0 +1,192 @@
+package fmter
+
+import (
+	"io"
+	"iter"
+	"slices"
+)
+
+// Overflow selects what a streaming table does with a row that is wider than
+// the column widths chosen from its sample. See [WithStreamingTable].
+type Overflow int
+
+const (
+	// OverflowTruncate truncates wide cells to the chosen widths.
+	OverflowTruncate Overflow = iota
+	// OverflowRepaginate ends the table and starts a new one, with a repeated
+	// header, whose columns are wide enough for the row.
+	OverflowRepaginate
+)
+
+// streamNumberWidth is the width reserved for the [Numbered] column of a
+// streaming table, enough for row numbers below ten million.
+const streamNumberWidth = 7
+
+type streamTableOptions struct {
+	sample   int
+	overflow Overflow
+}
+
+// WithStreamingTable makes [WriteIter] and [WriteChan] render Table
+// incrementally instead of collecting every item first. Column widths are
+// chosen from the header, the first sample rows (at least one), and
+// [Truncated] or [Wrapped] hints, which fix a column at the hinted width.
+// Rows are then written as they arrive, so memory stays bounded by the
+// sample size; overflow decides what happens to a later row that does not
+// fit. It has no effect on [Write], or when sorting requires every item.
+func WithStreamingTable(sample int, overflow Overflow) Option {
+	return func(o *options) {
+		o.streamTable = &streamTableOptions{sample: max(sample, 1), overflow: overflow}
+	}
+}
+
+func streamTable[T any](w io.Writer, seq iter.Seq[T], o *options) error {
+	var (
+		sample    []T
+		spec      *tableSpec
+		tw        *tableWriter
+		agg       *aggregator
+		n         int
+		streamErr error
+	)
+	// rowAt returns the row of the item at index i, read from its Formatter
+	// output when it has one.
+	rowAt := func(item T, i int) ([]string, error) {
+		data, err := formatItem(Table, item)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil {
+			return spec.cells(splitRow(data), i), nil
+		}
+		if missing := o.missingFor(Table, item); missing != nil {
+			return nil, newMissingInterfaceError[T](Table, item, i, missing...)
+		}
+		if err := o.checkRow(item, i); err != nil {
+			return nil, err
+		}
+		return spec.row(item, i, o), nil
+	}
+	write := func(item T) error {
+		row, err := rowAt(item, n)
+		if err != nil {
+			return err
+		}
+		n++
+		agg.add(row)
+		if o.streamTable.overflow == OverflowRepaginate {
+			if widths, grown := spec.grow(row, spec.rowSpans(item, len(row)), tw.natural); grown {
+				// A row that only grows columns already shrunk to fit the
+				// maximum width doesn't need a new table.
+				if fitted, _ := spec.fit(widths); slices.Equal(fitted, tw.widths) {
+					tw.natural = widths
+				} else if err := tw.restart(widths); err != nil {
+					return err
+				}
+			}
+		}
+		return tw.row(row, item)
+	}
+	start := func() error {
+		var err error
+		if spec, err = newTableSpec(w, any(sample[0]), o); err != nil {
+			return err
+		}
+		agg = newAggregator(spec.aggregates)
+		rows := make([][]string, len(sample))
+		spans := make([][]int, len(sample))
+		for i, item := range sample {
+			if rows[i], err = rowAt(item, i); err != nil {
+				return err
+			}
+			spans[i] = spec.rowSpans(item, len(rows[i]))
+			agg.add(rows[i])
+		}
+		tw = newTableWriter(w, spec, spec.streamWidths(rows, spans))
+		if err := tw.begin(spec.title); err != nil {
+			return err
+		}
+		for i, row := range rows {
+			if err := tw.row(row, sample[i]); err != nil {
+				return err
+			}
+		}
+		n, sample = len(sample), nil
+		return nil
+	}
+
+	seq(func(item T) bool {
+		if tw != nil {
+			streamErr = write(item)
+			return streamErr == nil
+		}
+		sample = append(sample, item)
+		if len(sample) == o.streamTable.sample {
+			streamErr = start()
+		}
+		return streamErr == nil
+	})
+	if streamErr != nil {
+		return streamErr
+	}
+	if tw == nil {
+		if len(sample) == 0 {
+			return nil
+		}
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	spec.footer = agg.footer(spec.footer)
+	return tw.end()
+}
+
+// streamWidths sizes columns to fit the sampled rows and their spans, then
+// fixes columns with a [Truncated] or [Wrapped] hint at the hinted width and
+// reserves room for row numbers.
+func (s *tableSpec) streamWidths(rows [][]string, spans [][]int) []int {
+	widths := s.widths(rows, spans)
+	for i := range widths {
+		if i < len(s.maxWidths) && s.maxWidths[i] > 0 {
+			widths[i] = s.maxWidths[i]
+		}
+		if i < len(s.wrapWidths) && s.wrapWidths[i] > 0 {
+			widths[i] = s.wrapWidths[i]
+		}
+	}
+	if s.numbered {
+		widths[0] = max(widths[0], streamNumberWidth)
+	}
+	return widths
+}
+
+// grow returns widths widened to fit row, laid out by spans, and whether any
+// column grew. Columns fixed by a hint never grow to fit a cell of their own.
+func (s *tableSpec) grow(row []string, spans []int, widths []int) ([]int, bool) {
+	grown := false
+	for i, cell := range unspanned(row, spans) {
+		if (i < len(s.maxWidths) && s.maxWidths[i] > 0) || (i < len(s.wrapWidths) && s.wrapWidths[i] > 0) {
+			continue
+		}
+		cw := cellWidth(cell)
+		if i < len(widths) && cw <= widths[i] {
+			continue
+		}
+		if !grown {
+			widths = slices.Clone(widths)
+			grown = true
+		}
+		for len(widths) <= i {
+			widths = append(widths, 0)
+		}
+		widths[i] = cw
+	}
+	if spans != nil {
+		wider := slices.Clone(widths)
+		s.widen(wider, row, spans)
+		if !slices.Equal(wider, widths) {
+			widths, grown = wider, true
+		}
+	}
+	return widths, grown
+}
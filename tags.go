@@ -0,0 +1,219 @@
+package fmter
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// tagKey is the struct tag consulted when an item does not implement [Rower].
+//
+//	type Service struct {
+//		Name string  `fmter:"Name"`
+//		Port int     `fmter:"Port,align=right,width=6"`
+//		Load float64 `fmter:"Load,format=%.2f"`
+//		Note string  `fmter:"-"`
+//	}
+//
+// The first element is the column header (the field name when empty). The
+// remaining key=value pairs set the column alignment (left, center, right),
+// the maximum width used for truncation, and the fmt verb used to render the
+// value. Unknown keys and invalid values are ignored.
+const tagKey = "fmter"
+
+type tagField struct {
+	index  []int
+	header string
+	align  Alignment
+	width  int
+	format string
+}
+
+type tagSpec struct {
+	fields []tagField
+	header []string
+	aligns []Alignment
+	widths []int
+}
+
+var tagSpecs sync.Map // reflect.Type -> *tagSpec (nil when the type has no tags)
+
+// specOf returns the cached tag spec for v's type, or nil if v is not a
+// struct (or pointer to struct) with at least one fmter tag.
+func specOf(v any) *tagSpec {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil
+	}
+	if cached, ok := tagSpecs.Load(t); ok {
+		spec, _ := cached.(*tagSpec)
+		return spec
+	}
+	spec := buildSpec(t)
+	tagSpecs.Store(t, spec)
+	return spec
+}
+
+func buildSpec(t reflect.Type) *tagSpec {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	fields := collectTagFields(t, nil)
+	if len(fields) == 0 {
+		return nil
+	}
+	spec := &tagSpec{fields: fields}
+	for _, f := range fields {
+		spec.header = append(spec.header, f.header)
+		spec.aligns = append(spec.aligns, f.align)
+		spec.widths = append(spec.widths, f.width)
+	}
+	return spec
+}
+
+func collectTagFields(t reflect.Type, parent []int) []tagField {
+	var fields []tagField
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		index := append(append([]int(nil), parent...), i)
+		tag, tagged := sf.Tag.Lookup(tagKey)
+		if !tagged {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && ft.Kind() == reflect.Struct {
+				fields = append(fields, collectTagFields(ft, index)...)
+			}
+			continue
+		}
+		if tag == "-" || !sf.IsExported() {
+			continue
+		}
+		fields = append(fields, parseTagField(sf.Name, tag, index))
+	}
+	return fields
+}
+
+func parseTagField(name, tag string, index []int) tagField {
+	parts := strings.Split(tag, ",")
+	f := tagField{index: index, header: parts[0]}
+	if f.header == "" {
+		f.header = name
+	}
+	for _, part := range parts[1:] {
+		key, val, _ := strings.Cut(part, "=")
+		switch key {
+		case "align":
+			switch val {
+			case "center":
+				f.align = AlignCenter
+			case "right":
+				f.align = AlignRight
+			default:
+				f.align = AlignLeft
+			}
+		case "width":
+			if n, err := strconv.Atoi(val); err == nil && n > 0 {
+				f.width = n
+			}
+		case "format":
+			f.format = val
+		}
+	}
+	return f
+}
+
+func (s *tagSpec) row(v any) []string {
+	rv := reflect.ValueOf(v)
+	row := make([]string, len(s.fields))
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return row
+		}
+		rv = rv.Elem()
+	}
+	for i, f := range s.fields {
+		fv, err := rv.FieldByIndexErr(f.index)
+		if err != nil || !fv.CanInterface() {
+			continue // nil embedded pointer
+		}
+		row[i] = formatTagValue(fv, f.format)
+	}
+	return row
+}
+
+func formatTagValue(v reflect.Value, format string) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		if _, ok := v.Interface().(fmt.Stringer); ok && format == "" {
+			break
+		}
+		v = v.Elem()
+	}
+	if format != "" {
+		return fmt.Sprintf(format, v.Interface())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// rowOf returns the row for v from [Rower] or, failing that, its struct tags.
+// It returns nil when v has neither; callers check [isRower] first.
+func rowOf(v any) []string {
+	if r, ok := v.(Rower); ok {
+		return r.Row()
+	}
+	if spec := specOf(v); spec != nil {
+		return spec.row(v)
+	}
+	return nil
+}
+
+// isRower reports whether rows can be produced for v.
+func isRower(v any) bool {
+	if _, ok := v.(Rower); ok {
+		return true
+	}
+	return specOf(v) != nil
+}
+
+// headerOf returns the header for v from [Headed] or its struct tags.
+func headerOf(v any) ([]string, bool) {
+	if h, ok := v.(Headed); ok {
+		return h.Header(), true
+	}
+	if spec := specOf(v); spec != nil {
+		return spec.header, true
+	}
+	return nil, false
+}
+
+// alignsOf returns the alignments for v from [Aligned] or its struct tags.
+func alignsOf(v any) []Alignment {
+	if a, ok := v.(Aligned); ok {
+		return a.Alignments()
+	}
+	if spec := specOf(v); spec != nil {
+		return spec.aligns
+	}
+	return nil
+}
+
+// maxWidthsOf returns the maximum widths for v from [Truncated] or its struct
+// tags.
+func maxWidthsOf(v any) []int {
+	if tr, ok := v.(Truncated); ok {
+		return tr.MaxWidths()
+	}
+	if spec := specOf(v); spec != nil {
+		return spec.widths
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package fmter
+
+import (
+	"io"
+	"os"
+	"strconv"
+)
+
+// terminalWidth returns the width of the terminal w writes to, or 0 when w is
+// not a terminal.
+func terminalWidth(w io.Writer) int {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return 0
+	}
+	return ttyWidth(f.Fd(), os.Getenv("COLUMNS"))
+}
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+	_, ok = ttyColumns(f.Fd())
+	return ok
+}
+
+// ttyWidth returns the width of the terminal open on fd: columns when it is a
+// positive number, else the size the terminal reports. It returns 0 when fd
+// is not a terminal.
+func ttyWidth(fd uintptr, columns string) int {
+	size, ok := ttyColumns(fd)
+	if !ok {
+		return 0
+	}
+	if n, err := strconv.Atoi(columns); err == nil && n > 0 {
+		return n
+	}
+	return size
+}
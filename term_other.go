@@ -0,0 +1,9 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package fmter
+
+// ttyColumns reports that fd is not a terminal on platforms without
+// TIOCGWINSZ, so tables are only fitted with [WithMaxWidth].
+func ttyColumns(uintptr) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,15 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package fmter
+
+import "golang.org/x/sys/unix"
+
+// ttyColumns returns the column count of the terminal open on fd, and whether
+// fd is a terminal.
+func ttyColumns(fd uintptr) (int, bool) {
+	ws, err := unix.IoctlGetWinsize(int(fd), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, false
+	}
+	return int(ws.Col), true
+}
@@ -0,0 +1,113 @@
+package fmter
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Theme styles the parts of a Table other than its data cells, which
+// [Styled] covers. Each field wraps the text it is given, typically in ANSI
+// escape codes; a nil field leaves that part unstyled. Themes are only
+// applied when color is enabled; see [WithColor].
+type Theme struct {
+	Border    func(string) string // border lines and column separators
+	Header    func(string) string // header cells
+	Title     func(string) string // title text
+	Footer    func(string) string // footer cells
+	Caption   func(string) string // caption line
+	Separator func(string) string // lines between row groups; nil uses Border
+}
+
+// Built-in themes.
+var (
+	// ThemeDark suits terminals with a dark background.
+	ThemeDark = Theme{
+		Border:  sgr("90"),
+		Header:  sgr("1;36"),
+		Title:   sgr("1;97"),
+		Footer:  sgr("1"),
+		Caption: sgr("90"),
+	}
+	// ThemeLight suits terminals with a light background.
+	ThemeLight = Theme{
+		Border:  sgr("37"),
+		Header:  sgr("1;34"),
+		Title:   sgr("1;30"),
+		Footer:  sgr("1"),
+		Caption: sgr("90"),
+	}
+	// ThemeMonochrome uses bold and faint text only, no colors.
+	ThemeMonochrome = Theme{
+		Border:  sgr("2"),
+		Header:  sgr("1"),
+		Title:   sgr("1"),
+		Footer:  sgr("1"),
+		Caption: sgr("2"),
+	}
+)
+
+// sgr returns a style that wraps text in the ANSI select graphic rendition
+// sequence for params, resetting afterwards.
+func sgr(params string) func(string) string {
+	return func(s string) string {
+		return "\x1b[" + params + "m" + s + "\x1b[0m"
+	}
+}
+
+// WithTheme overrides [Themed].
+func WithTheme(t Theme) Option {
+	return func(o *options) { o.theme = &t }
+}
+
+// WithColor forces the [Theme] on or off, as for a --color flag. By default
+// it is applied only when writing to a terminal, unless the NO_COLOR
+// environment variable is set. FORCE_COLOR applies it even when not writing
+// to a terminal, except that FORCE_COLOR=0 or FORCE_COLOR=false turns it off
+// everywhere. [Styled] column styles are always applied.
+func WithColor(enabled bool) Option {
+	return func(o *options) { o.color = &enabled }
+}
+
+// themeFor returns the theme to draw v's table with, or the zero Theme when
+// color is disabled for w.
+func (o *options) themeFor(w io.Writer, v any) Theme {
+	enabled := colorEnabled(isTerminal(w), os.Getenv)
+	if o.color != nil {
+		enabled = *o.color
+	}
+	if !enabled {
+		return Theme{}
+	}
+	if o.theme != nil {
+		return *o.theme
+	}
+	if t, ok := v.(Themed); ok {
+		return t.Theme()
+	}
+	return Theme{}
+}
+
+// colorEnabled reports whether to emit color by default: NO_COLOR disables
+// it, FORCE_COLOR enables it, or disables it when "0" or "false", and
+// otherwise it follows tty.
+func colorEnabled(tty bool, getenv func(string) string) bool {
+	if getenv("NO_COLOR") != "" {
+		return false
+	}
+	switch force := getenv("FORCE_COLOR"); {
+	case force == "0" || strings.EqualFold(force, "false"):
+		return false
+	case force != "":
+		return true
+	}
+	return tty
+}
+
+// paint applies style to s, if there is one.
+func paint(style func(string) string, s string) string {
+	if style == nil {
+		return s
+	}
+	return style(s)
+}
@@ -0,0 +1,323 @@
+package fmter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WithTree nests the children of [Parent] items under a "children" key in
+// JSON, JSONL, and YAML output, so that it matches the Tree format. Without
+// it, items are encoded as they are, whether or not they are Parents.
+func WithTree() Option {
+	return func(o *options) { o.tree = true }
+}
+
+// nests reports whether v is encoded with its children nested.
+func (o *options) nests(v any) bool {
+	return o.tree && isParent(v)
+}
+
+// maxTreeDepth bounds how deeply children nest. Cycles through pointers are
+// caught as soon as they close; the bound also ends cycles of values, which
+// have no identity to recognize them by.
+const maxTreeDepth = 1000
+
+// treePath holds the nodes from the root of a tree down to the node being
+// visited.
+type treePath []any
+
+// enter returns the path extended by v, or an error when v is a pointer
+// already on the path, listing itself or an ancestor as a child, or the path
+// is too deep.
+func (p treePath) enter(v any) (treePath, error) {
+	if len(p) >= maxTreeDepth {
+		return nil, fmt.Errorf("%w: cycle in Children(), or children nested deeper than %d", ErrInvalidFormat, maxTreeDepth)
+	}
+	if reflect.ValueOf(v).Kind() == reflect.Pointer {
+		for _, node := range p {
+			// Only pointers of the same type compare equal, so comparing
+			// with any node is safe.
+			if node == v {
+				return nil, fmt.Errorf("%w: cycle in Children() of %T", ErrInvalidFormat, v)
+			}
+		}
+	}
+	return append(p, v), nil
+}
+
+// treeChars are the connectors drawn in front of tree nodes.
+type treeChars struct {
+	branch string // a child with siblings below it
+	last   string // the last child
+	pipe   string // the indent below a branch, continuing its line
+	space  string // the indent below the last child
+}
+
+var treeSets = map[BorderStyle]treeChars{
+	BorderRounded: {branch: "├── ", last: "└── ", pipe: "│   ", space: "    "},
+	BorderASCII:   {branch: "|-- ", last: "`-- ", pipe: "|   ", space: "    "},
+	BorderHeavy:   {branch: "┣━━ ", last: "┗━━ ", pipe: "┃   ", space: "    "},
+	BorderDouble:  {branch: "╠══ ", last: "╚══ ", pipe: "║   ", space: "    "},
+	BorderNone:    {branch: "  ", last: "  ", pipe: "  ", space: "  "},
+}
+
+// treeCharsFor returns the connectors for v's border style. Custom border
+// sets draw them from their tees, corners, and lines.
+func (o *options) treeCharsFor(v any) treeChars {
+	if tc, ok := treeSets[o.borderFor(v)]; ok && o.borderSet == nil {
+		return tc
+	}
+	bs, _ := o.borderSetFor(v)
+	branch := bs.LeftTee + strings.Repeat(bs.Horizontal, 2) + " "
+	width := displayWidth(branch)
+	return treeChars{
+		branch: branch,
+		last:   bs.BottomLeft + strings.Repeat(bs.Horizontal, 2) + " ",
+		pipe:   bs.Vertical + strings.Repeat(" ", max(width-displayWidth(bs.Vertical), 0)),
+		space:  strings.Repeat(" ", width),
+	}
+}
+
+// treeLine is one node of a rendered tree: its connectors and cells, or the
+// verbatim output of a top-level [Formatter].
+type treeLine struct {
+	prefix string
+	cells  []string
+	aligns []Alignment
+	raw    []byte
+}
+
+func writeTree[T any](w io.Writer, items []T, o *options) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := checkItems(Tree, items, o); err != nil {
+		return err
+	}
+	first := any(items[0])
+	tb := &treeBuilder{o: o, tc: o.treeCharsFor(first)}
+	for i, item := range items {
+		if data, ok := o.formattedAt(i); ok {
+			tb.lines = append(tb.lines, treeLine{raw: data})
+			continue
+		}
+		if err := tb.add(item, nil, "", "", ""); err != nil {
+			return err
+		}
+	}
+	var header []string
+	if o.isRower(first) {
+		header = o.headerFor(first)
+	}
+
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = displayWidth(h)
+	}
+	for _, line := range tb.lines {
+		for i, cell := range line.cells {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if i == 0 {
+				cell = line.prefix + cell
+			}
+			widths[i] = max(widths[i], displayWidth(cell))
+		}
+	}
+
+	theme := o.themeFor(w, first)
+	var sb strings.Builder
+	if header != nil {
+		writeTreeLine(&sb, "", nil, theme.Header, header, o.alignsFor(first), widths)
+	}
+	for _, line := range tb.lines {
+		if line.raw != nil {
+			sb.Write(line.raw)
+			continue
+		}
+		writeTreeLine(&sb, line.prefix, theme.Border, nil, line.cells, line.aligns, widths)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeTreeLine writes a node's label after its connectors, followed by its
+// remaining cells in aligned columns. The connectors are painted with border
+// and the padded cells with style.
+func writeTreeLine(sb *strings.Builder, prefix string, border, style func(string) string, cells []string, aligns []Alignment, widths []int) {
+	var line string
+	if prefix != "" {
+		line = paint(border, prefix)
+	}
+	label := at(cells, 0)
+	if len(cells) > 1 {
+		label = alignCell(label, widths[0]-displayWidth(prefix), AlignLeft)
+	}
+	line += paint(style, label)
+	for i := 1; i < len(cells); i++ {
+		line += "  " + paint(style, alignCell(cells[i], widths[i], at(aligns, i)))
+	}
+	sb.WriteString(strings.TrimRight(line, " ") + "\n")
+}
+
+// treeBuilder flattens a forest of [Parent] items into lines, depth first.
+type treeBuilder struct {
+	o     *options
+	tc    treeChars
+	lines []treeLine
+}
+
+// add appends v, below its ancestors on path and drawn after indent and its
+// connector, and then its children, which are indented by childIndent.
+func (tb *treeBuilder) add(v any, path treePath, indent, connector, childIndent string) error {
+	path, err := path.enter(v)
+	if err != nil {
+		return err
+	}
+	tb.lines = append(tb.lines, treeLine{
+		prefix: indent + connector,
+		cells:  tb.cells(v),
+		aligns: tb.o.alignsFor(v),
+	})
+	children := treeChildren(v)
+	for i, child := range children {
+		if i == len(children)-1 {
+			err = tb.add(child, path, childIndent, tb.tc.last, childIndent+tb.tc.space)
+		} else {
+			err = tb.add(child, path, childIndent, tb.tc.branch, childIndent+tb.tc.pipe)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cells returns the row of v, whose first cell labels the node, or its
+// string form like [Plain] when v has no row.
+func (tb *treeBuilder) cells(v any) []string {
+	if tb.o.isRower(v) {
+		if row := tb.o.rowOf(v); len(row) > 0 {
+			return row
+		}
+	}
+	return []string{stringOf(v)}
+}
+
+// treeChildren returns the children of v, or nil when v is not a [Parent].
+func treeChildren(v any) []any {
+	if p, ok := v.(Parent); ok {
+		return p.Children()
+	}
+	return nil
+}
+
+func isParent[T any](item T) bool {
+	_, ok := any(item).(Parent)
+	return ok
+}
+
+// jsonTree returns v as JSON with its [Parent] children nested under a
+// "children" key, recursively. Objects gain the key unless they already have
+// one; other values are wrapped as {"value": ..., "children": [...]}.
+func jsonTree(v any) (json.RawMessage, error) {
+	return jsonNode(v, nil)
+}
+
+func jsonNode(v any, path treePath) (json.RawMessage, error) {
+	path, err := path.enter(v)
+	if err != nil {
+		return nil, err
+	}
+	data, err := marshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	children := treeChildren(v)
+	if len(children) == 0 {
+		return data, nil
+	}
+	nested := make([][]byte, len(children))
+	for i, child := range children {
+		if nested[i], err = jsonNode(child, path); err != nil {
+			return nil, err
+		}
+	}
+	kids := "[" + string(bytes.Join(nested, []byte(","))) + "]"
+	var fields map[string]json.RawMessage
+	if !bytes.HasPrefix(data, []byte("{")) || json.Unmarshal(data, &fields) != nil {
+		return json.RawMessage(`{"value":` + string(data) + `,"children":` + kids + "}"), nil
+	}
+	if _, ok := fields["children"]; ok {
+		return data, nil
+	}
+	sep := ","
+	if len(fields) == 0 {
+		sep = ""
+	}
+	return json.RawMessage(string(data[:len(data)-1]) + sep + `"children":` + kids + "}"), nil
+}
+
+// marshalJSON is json.Marshal without HTML escaping, which is left to the
+// encoder that writes the result.
+func marshalJSON(v any) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// yamlTree returns v as a YAML node with its [Parent] children nested like
+// [jsonTree].
+func yamlTree(v any) (*yaml.Node, error) {
+	return yamlNode(v, nil)
+}
+
+func yamlNode(v any, path treePath) (*yaml.Node, error) {
+	path, err := path.enter(v)
+	if err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	children := treeChildren(v)
+	if len(children) == 0 {
+		return node, nil
+	}
+	nested := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, child := range children {
+		kid, err := yamlNode(child, path)
+		if err != nil {
+			return nil, err
+		}
+		nested.Content = append(nested.Content, kid)
+	}
+	if node.Kind != yaml.MappingNode {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			yamlKey("value"), node, yamlKey("children"), nested,
+		}}, nil
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == "children" {
+			return node, nil
+		}
+	}
+	node.Content = append(node.Content, yamlKey("children"), nested)
+	return node, nil
+}
+
+func yamlKey(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
@@ -6,20 +6,24 @@ import (
 	"strings"
 )
 
-func writeTSV[T any](w io.Writer, items []T) error {
+func writeTSV[T any](w io.Writer, items []T, o *options) error {
 	if len(items) == 0 {
 		return nil
 	}
-	if _, ok := any(items[0]).(Rower); !ok {
-		return fmt.Errorf("%w: format %q requires Rower, not implemented by %T", ErrMissingInterface, TSV, items[0])
+	if err := checkItems(TSV, items, o); err != nil {
+		return err
 	}
-	if h, ok := any(items[0]).(Headed); ok {
-		if _, err := fmt.Fprintln(w, strings.Join(h.Header(), "\t")); err != nil {
+	cols, err := o.columnsFor(items[0])
+	if err != nil {
+		return err
+	}
+	if header := o.flatHeaderFor(items[0]); header != nil {
+		if _, err := fmt.Fprintln(w, strings.Join(pick(header, cols), "\t")); err != nil {
 			return err
 		}
 	}
-	for _, item := range items {
-		if _, err := fmt.Fprintln(w, strings.Join(any(item).(Rower).Row(), "\t")); err != nil {
+	for i, item := range items {
+		if _, err := fmt.Fprintln(w, strings.Join(pick(o.rowAt(i, item), cols), "\t")); err != nil {
 			return err
 		}
 	}
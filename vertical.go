@@ -0,0 +1,134 @@
+package fmter
+
+import (
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+func writeVertical[T any](w io.Writer, items []T, o *options) error {
+	if len(items) == 0 {
+		return nil
+	}
+	if err := checkItems(Vertical, items, o); err != nil {
+		return err
+	}
+	vw, err := newVerticalWriter(w, any(items[0]), o.rowAt(0, items[0]), o)
+	if err != nil {
+		return err
+	}
+	for i, item := range items {
+		if err := vw.record(i, o.rowAt(i, item)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func streamVertical[T any](w io.Writer, seq iter.Seq[T], o *options) error {
+	index := 0
+	var vw *verticalWriter
+	var streamErr error
+	seq(func(item T) bool {
+		i := index
+		index++
+		row, err := streamRow(Vertical, item, i, 0, o)
+		if err != nil {
+			streamErr = err
+			return false
+		}
+		if i == 0 {
+			if vw, err = newVerticalWriter(w, item, row, o); err != nil {
+				streamErr = err
+				return false
+			}
+		}
+		if err := vw.record(i, row); err != nil {
+			streamErr = err
+			return false
+		}
+		return true
+	})
+	return streamErr
+}
+
+// verticalWriter writes each row as a record of "Header: value" lines, with
+// the labels resolved once from the first item and its row.
+type verticalWriter struct {
+	w          io.Writer
+	cols       []int
+	title      string
+	labels     []string // padded so that values line up
+	indent     string   // continuation indent for wrapped values
+	styles     []func(string) string
+	wrapWidths []int
+}
+
+func newVerticalWriter(w io.Writer, first any, row []string, o *options) (*verticalWriter, error) {
+	cols, err := o.columnsFor(first)
+	if err != nil {
+		return nil, err
+	}
+	vw := &verticalWriter{w: w, cols: cols, title: o.titleFor(first)}
+	if st, ok := first.(Styled); ok {
+		vw.styles = pick(st.Styles(), cols)
+	}
+	if wr, ok := first.(Wrapped); ok {
+		vw.wrapWidths = pick(wr.WrapWidths(), cols)
+	}
+
+	// Without a header, label each value with its column number.
+	header := pick(o.headerFor(first), cols)
+	if header == nil {
+		for i := range pick(row, cols) {
+			header = append(header, strconv.Itoa(i+1))
+		}
+	}
+	labelWidth := 0
+	for _, h := range header {
+		labelWidth = max(labelWidth, displayWidth(h)+1)
+	}
+	vw.labels = make([]string, len(header))
+	for i, h := range header {
+		vw.labels[i] = alignCell(h+":", labelWidth, AlignLeft) + " "
+	}
+	vw.indent = strings.Repeat(" ", labelWidth+1)
+	return vw, nil
+}
+
+// record writes the row of the item at index i, preceded by the title for
+// the first record and a "-[ RECORD n ]" separator as wide as its longest
+// line.
+func (vw *verticalWriter) record(i int, row []string) error {
+	row = pick(row, vw.cols)
+	var lines []string
+	width := 0
+	for j, label := range vw.labels {
+		for k, part := range wrapCell(at(row, j), at(vw.wrapWidths, j)) {
+			prefix := label
+			if k > 0 {
+				prefix = vw.indent
+			}
+			if part == "" {
+				lines = append(lines, strings.TrimRight(prefix, " "))
+				continue
+			}
+			width = max(width, displayWidth(prefix)+displayWidth(part))
+			lines = append(lines, prefix+paint(at(vw.styles, j), part))
+		}
+	}
+
+	var sb strings.Builder
+	if i == 0 && vw.title != "" {
+		sb.WriteString(vw.title + "\n")
+	}
+	sep := fmt.Sprintf("-[ RECORD %d ]", i+1)
+	sb.WriteString(sep + strings.Repeat("-", max(width-displayWidth(sep), 1)) + "\n")
+	for _, line := range lines {
+		sb.WriteString(line + "\n")
+	}
+	_, err := io.WriteString(vw.w, sb.String())
+	return err
+}
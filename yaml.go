@@ -2,17 +2,29 @@ package fmter
 
 import (
 	"io"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
 
-func writeYAML[T any](w io.Writer, items []T) error {
+func writeYAML[T any](w io.Writer, items []T, o *options) error {
 	enc := yaml.NewEncoder(w)
 	if len(items) > 0 {
-		if ind, ok := any(items[0]).(Indented); ok {
-			enc.SetIndent(len(ind.Indent()))
+		if indent, ok := o.indentFor(items[0]); ok {
+			enc.SetIndent(len(indent))
 		}
 	}
+	if o.formatted != nil || (o.tree && slices.ContainsFunc(items, isParent[T])) {
+		elems, err := elements(items, o, yamlElement, yamlTree)
+		if err != nil {
+			return err
+		}
+		return encodeYAML(enc, elems)
+	}
+	return encodeYAML(enc, items)
+}
+
+func encodeYAML[T any](enc *yaml.Encoder, items []T) error {
 	if len(items) == 1 {
 		if err := enc.Encode(items[0]); err != nil {
 			return err